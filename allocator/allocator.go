@@ -1,15 +1,21 @@
 package allocator
 
-import "unsafe"
+import (
+	"reflect"
+	"unsafe"
+
+	"github.com/joetifa2003/mm-go"
+	"github.com/joetifa2003/mm-go/internal/debug"
+)
 
 // Allocator is an interface that defines some methods needed for most allocators.
 // It's not a golang interface, so it's safe to use in manually managed structs (will not get garbage collected).
 type Allocator struct {
-	allocator unsafe.Pointer
-	alloc     func(allocator unsafe.Pointer, size int) unsafe.Pointer
-	free      func(allocator unsafe.Pointer, ptr unsafe.Pointer)
-	realloc   func(allocator unsafe.Pointer, ptr unsafe.Pointer, size int) unsafe.Pointer
-	destroy   func(allocator unsafe.Pointer)
+	allocator unsafe.Pointer                                                              `mm:"manual"`
+	alloc     func(allocator unsafe.Pointer, size int) unsafe.Pointer                     `mm:"static"`
+	free      func(allocator unsafe.Pointer, ptr unsafe.Pointer)                          `mm:"static"`
+	realloc   func(allocator unsafe.Pointer, ptr unsafe.Pointer, size int) unsafe.Pointer `mm:"static"`
+	destroy   func(allocator unsafe.Pointer)                                              `mm:"static"`
 }
 
 // NewAllocator creates a new Allocator
@@ -20,6 +26,8 @@ func NewAllocator(
 	realloc func(allocator unsafe.Pointer, ptr unsafe.Pointer, size int) unsafe.Pointer,
 	destroy func(allocator unsafe.Pointer),
 ) Allocator {
+	debug.AllocatorCreated(allocator)
+
 	return Allocator{
 		allocator: allocator,
 		alloc:     alloc,
@@ -29,18 +37,33 @@ func NewAllocator(
 	}
 }
 
+// Pointer returns the allocator state pointer that was passed to NewAllocator.
+// This is useful for custom allocators that need to get back their internal state.
+func (a Allocator) Pointer() unsafe.Pointer {
+	return a.allocator
+}
+
+// HasAllocFunc reports whether the allocator was created by NewAllocator with alloc as its alloc function,
+// custom allocators use it to make sure an Allocator is theirs before casting Pointer to their state.
+func (a Allocator) HasAllocFunc(alloc func(allocator unsafe.Pointer, size int) unsafe.Pointer) bool {
+	return a.alloc != nil && reflect.ValueOf(a.alloc).Pointer() == reflect.ValueOf(alloc).Pointer()
+}
+
 // Alloc allocates size bytes and returns an unsafe pointer to it.
 func (a Allocator) Alloc(size int) unsafe.Pointer {
+	debug.CheckAllocator(a.allocator)
 	return a.alloc(a.allocator, size)
 }
 
 // Free frees the memory pointed by ptr
 func (a Allocator) Free(ptr unsafe.Pointer) {
+	debug.CheckAllocator(a.allocator)
 	a.free(a.allocator, ptr)
 }
 
 // Realloc reallocates the memory pointed by ptr with a new size and returns a new pointer to it.
 func (a Allocator) Realloc(ptr unsafe.Pointer, size int) unsafe.Pointer {
+	debug.CheckAllocator(a.allocator)
 	return a.realloc(a.allocator, ptr, size)
 }
 
@@ -48,6 +71,7 @@ func (a Allocator) Realloc(ptr unsafe.Pointer, size int) unsafe.Pointer {
 // After calling this, the allocator is no longer usable.
 // This is useful for cleanup, freeing allocator internal resources, etc.
 func (a Allocator) Destroy() {
+	debug.AllocatorDestroyed(a.allocator)
 	a.destroy(a.allocator)
 }
 
@@ -58,21 +82,23 @@ func getSize[T any]() int {
 
 // Alloc allocates T and returns a pointer to it.
 func Alloc[T any](a Allocator) *T {
-	ptr := a.alloc(a.allocator, getSize[T]())
+	mm.CheckGoPointers[T]()
+	ptr := a.Alloc(getSize[T]())
 	return (*T)(unsafe.Pointer(ptr))
 }
 
 // FreeMany frees memory allocated by Alloc takes a ptr
 // CAUTION: be careful not to double free, and prefer using defer to deallocate
 func Free[T any](a Allocator, ptr *T) {
-	a.free(a.allocator, unsafe.Pointer(ptr))
+	a.Free(unsafe.Pointer(ptr))
 }
 
 // AllocMany allocates n of T and returns a slice representing the heap.
 // CAUTION: don't append to the slice, the purpose of it is to replace pointer
 // arithmetic with slice indexing
 func AllocMany[T any](a Allocator, n int) []T {
-	ptr := a.alloc(a.allocator, getSize[T]()*n)
+	mm.CheckGoPointers[T]()
+	ptr := a.Alloc(getSize[T]() * n)
 	return unsafe.Slice(
 		(*T)(ptr),
 		n,
@@ -82,12 +108,12 @@ func AllocMany[T any](a Allocator, n int) []T {
 // FreeMany frees memory allocated by AllocMany takes in the slice (aka the heap)
 // CAUTION: be careful not to double free, and prefer using defer to deallocate
 func FreeMany[T any](a Allocator, slice []T) {
-	a.free(a.allocator, unsafe.Pointer(&slice[0]))
+	a.Free(unsafe.Pointer(unsafe.SliceData(slice)))
 }
 
 // Realloc reallocates memory allocated with AllocMany and doesn't change underling data
 func Realloc[T any](a Allocator, slice []T, newN int) []T {
-	ptr := a.realloc(a.allocator, unsafe.Pointer(&slice[0]), getSize[T]()*newN)
+	ptr := a.Realloc(unsafe.Pointer(unsafe.SliceData(slice)), getSize[T]()*newN)
 	return unsafe.Slice(
 		(*T)(ptr),
 		newN,
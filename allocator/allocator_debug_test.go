@@ -0,0 +1,26 @@
+//go:build mmdebug
+
+package allocator_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/batchallocator"
+)
+
+func TestAllocatorUseAfterDestroy(t *testing.T) {
+	assert := assert.New(t)
+
+	alloc := allocator.NewC()
+	alloc.Destroy()
+
+	assert.PanicsWithValue("allocator used after Destroy", func() { allocator.Alloc[int](alloc) })
+	assert.PanicsWithValue("allocator destroyed twice", func() { alloc.Destroy() })
+
+	batch := batchallocator.New(allocator.NewC())
+	batch.Destroy()
+	assert.PanicsWithValue("allocator used after Destroy", func() { batch.Alloc(8) })
+}
@@ -3,11 +3,22 @@ package allocator
 // #include <stdlib.h>
 import "C"
 
-import "unsafe"
+import (
+	"unsafe"
+
+	"github.com/joetifa2003/mm-go/internal/debug"
+)
 
 // NewC returns an allocator that uses C calloc, realloc and free.
 func NewC() Allocator {
-	return NewAllocator(nil, callocator_alloc, callocator_free, callocator_realloc, callocator_destroy)
+	var state unsafe.Pointer
+	if debug.Enabled {
+		// the C allocator is stateless, in debug builds a unique state is needed to detect using it after Destroy.
+		// it's never freed so it's never reused by another allocator.
+		state = C.malloc(1)
+	}
+
+	return NewAllocator(state, callocator_alloc, callocator_free, callocator_realloc, callocator_destroy)
 }
 
 func callocator_alloc(allocator unsafe.Pointer, size int) unsafe.Pointer {
@@ -21,10 +21,11 @@ const (
 )
 
 type bucket struct {
-	data   unsafe.Pointer // Base pointer of the bucket memory
+	data   unsafe.Pointer `mm:"manual"` // Base pointer of the bucket memory
 	offset uintptr        // Number of used bytes in this bucket
 	size   uintptr        // Total size of this bucket
 	ptrs   int            // Number of pointers (allocations) inside the bucket
+	live   uintptr        // Number of bytes used by live allocations (excluding metadata)
 }
 
 func (b *bucket) Free(a allocator.Allocator) {
@@ -96,6 +97,7 @@ func batchAllocatorAlloc(allocator unsafe.Pointer, size int) unsafe.Pointer {
 
 		currentBucket.offset = align(currentBucket.offset+sizeOfPtrMeta+uintptr(size), alignment)
 		currentBucket.ptrs++
+		currentBucket.live += uintptr(size)
 
 		balloc.buckets.Push(meta.bucket)
 
@@ -107,6 +109,7 @@ func batchAllocatorAlloc(allocator unsafe.Pointer, size int) unsafe.Pointer {
 	newBucket := allocateNewBucket(balloc, size)
 	newBucket.offset = align(sizeOfPtrMeta+uintptr(size), alignment)
 	newBucket.ptrs++
+	newBucket.live += uintptr(size)
 	balloc.buckets.Push(newBucket)
 
 	// Write meta information at the base of the new bucket
@@ -124,6 +127,7 @@ func batchAllocatorFree(allocator unsafe.Pointer, ptr unsafe.Pointer) {
 	// Retrieve the metadata by moving back
 	meta := (*ptrMeta)(unsafe.Pointer(uintptr(ptr) - sizeOfPtrMeta))
 	meta.bucket.ptrs--
+	meta.bucket.live -= uintptr(meta.size)
 
 	// If no more pointers exist in the bucket, free the bucket
 	if meta.bucket.ptrs == 0 {
@@ -155,12 +159,15 @@ func batchAllocatorRealloc(allocator unsafe.Pointer, ptr unsafe.Pointer, size in
 func batchAllocatorDestroy(a unsafe.Pointer) {
 	balloc := (*BatchAllocator)(a)
 
-	// Free all buckets in the heap
-	for _, b := range balloc.buckets.Iter() {
-		b.Free(balloc.alloc)
+	// Free all buckets in the heap, the heap is only created on the first allocation
+	if balloc.buckets != nil {
+		for _, b := range balloc.buckets.Iter() {
+			b.Free(balloc.alloc)
+		}
+
+		balloc.buckets.Free()
 	}
 
-	balloc.buckets.Free()
 	allocator.Free(balloc.alloc, balloc)
 }
 
@@ -1,18 +1,21 @@
 package batchallocator
 
 import (
+	"io"
+	"strings"
 	"testing"
 	"unsafe"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/mmtest"
 )
 
 func TestBatchAllocator(t *testing.T) {
 	assert := require.New(t)
 
-	alloc := New(allocator.NewC())
+	alloc := New(mmtest.NewAllocator(t))
 
 	i := allocator.Alloc[int](alloc)
 	*i = 1
@@ -41,7 +44,8 @@ func TestBatchAllocator(t *testing.T) {
 func TestBatchAllocatorAligned(t *testing.T) {
 	assert := require.New(t)
 
-	alloc := New(allocator.NewC())
+	alloc := New(mmtest.NewAllocator(t))
+	defer alloc.Destroy()
 
 	alloc.Alloc(13)
 	alloc.Alloc(11)
@@ -50,3 +54,56 @@ func TestBatchAllocatorAligned(t *testing.T) {
 
 	assert.Equal(0, int(uintptr(unsafe.Pointer(y))%8))
 }
+
+func TestBatchAllocatorStats(t *testing.T) {
+	assert := require.New(t)
+
+	alloc := New(mmtest.NewAllocator(t))
+	defer alloc.Destroy()
+
+	stats, ok := GetStats(alloc)
+	assert.True(ok)
+	assert.Equal(0, len(stats.Buckets))
+	assert.Equal(0.0, stats.Fragmentation)
+
+	i := allocator.Alloc[int](alloc)
+	j := allocator.Alloc[int](alloc)
+
+	stats, _ = GetStats(alloc)
+	assert.Equal(1, len(stats.Buckets))
+	assert.Equal(2, stats.LivePtrs)
+	assert.Equal(16, stats.LiveBytes)
+	assert.Equal(2*(int(sizeOfPtrMeta)+8), stats.UsedBytes)
+	assert.Equal(pageSize, stats.ReservedBytes)
+
+	allocator.Free(alloc, i)
+
+	stats, _ = GetStats(alloc)
+	assert.Equal(1, stats.LivePtrs)
+	assert.Equal(8, stats.LiveBytes)
+	assert.Equal(1, stats.Buckets[0].Ptrs)
+	assert.InDelta(1-8.0/float64(stats.UsedBytes), stats.Fragmentation, 0.0001)
+
+	var buf strings.Builder
+	assert.NoError(Dump(alloc, &buf))
+	assert.Contains(buf.String(), "buckets: 1")
+	assert.Contains(buf.String(), "bucket 0:")
+
+	allocator.Free(alloc, j)
+
+	stats, _ = GetStats(alloc)
+	assert.Equal(0, len(stats.Buckets))
+}
+
+func TestBatchAllocatorStatsNotBatch(t *testing.T) {
+	assert := require.New(t)
+
+	calloc := allocator.NewC()
+	defer calloc.Destroy()
+
+	for _, alloc := range []allocator.Allocator{calloc, mmtest.NewAllocator(t)} {
+		_, ok := GetStats(alloc)
+		assert.False(ok)
+		assert.ErrorIs(Dump(alloc, io.Discard), ErrNotBatchAllocator)
+	}
+}
@@ -61,3 +61,23 @@ func ExampleWithBucketSize() {
 	defer allocator.Free(alloc, ptr2)   // this can be removed and the memory will still be freed on Destroy.
 
 }
+
+func ExampleGetStats() {
+	alloc := batchallocator.New(allocator.NewC())
+	defer alloc.Destroy()
+
+	a := allocator.Alloc[int](alloc)
+	b := allocator.Alloc[int](alloc)
+	allocator.Free(alloc, a) // the bucket can't be reused until b is freed too
+	_ = b
+
+	stats, _ := batchallocator.GetStats(alloc)
+	fmt.Println(len(stats.Buckets))
+	fmt.Println(stats.LivePtrs)
+	fmt.Println(stats.LiveBytes)
+
+	// Output:
+	// 1
+	// 1
+	// 8
+}
@@ -0,0 +1,113 @@
+package batchallocator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/joetifa2003/mm-go/allocator"
+)
+
+// BucketStats describes a single bucket of a BatchAllocator
+type BucketStats struct {
+	Size      int // Total size of the bucket in bytes
+	Offset    int // Number of bytes consumed from the bucket (including metadata and padding)
+	Ptrs      int // Number of live pointers inside the bucket
+	LiveBytes int // Number of bytes used by live pointers (excluding metadata)
+}
+
+// Stats is a snapshot of the state of a BatchAllocator
+type Stats struct {
+	Buckets       []BucketStats
+	ReservedBytes int // Total bytes reserved from the underlying allocator by all buckets
+	UsedBytes     int // Total bytes consumed from all buckets (including freed holes, metadata and padding)
+	LiveBytes     int // Total bytes used by live pointers
+	LivePtrs      int // Total number of live pointers
+
+	// Fragmentation is the ratio of consumed bytes that are not used by live pointers,
+	// it's 0 when there is no waste and approaches 1 when most of the consumed memory is freed holes.
+	// Memory in a bucket can't be reused until all pointers in the bucket are freed.
+	Fragmentation float64
+}
+
+// ErrNotBatchAllocator is returned by Dump when the allocator was not created using New
+var ErrNotBatchAllocator = errors.New("not a batch allocator")
+
+// GetStats returns a snapshot of the buckets of a BatchAllocator,
+// ok is false if alloc was not created using New.
+func GetStats(alloc allocator.Allocator) (stats Stats, ok bool) {
+	balloc, ok := getBatchAllocator(alloc)
+	if !ok || balloc.buckets == nil {
+		return stats, ok
+	}
+
+	for _, b := range balloc.buckets.Iter() {
+		stats.Buckets = append(stats.Buckets, BucketStats{
+			Size:      int(b.size),
+			Offset:    int(b.offset),
+			Ptrs:      b.ptrs,
+			LiveBytes: int(b.live),
+		})
+
+		stats.ReservedBytes += int(b.size)
+		stats.UsedBytes += int(b.offset)
+		stats.LiveBytes += int(b.live)
+		stats.LivePtrs += b.ptrs
+	}
+
+	if stats.UsedBytes != 0 {
+		stats.Fragmentation = 1 - float64(stats.LiveBytes)/float64(stats.UsedBytes)
+	}
+
+	return stats, true
+}
+
+// Dump writes a human readable report of the BatchAllocator buckets to w,
+// it returns ErrNotBatchAllocator if alloc was not created using New.
+func Dump(alloc allocator.Allocator, w io.Writer) error {
+	stats, ok := GetStats(alloc)
+	if !ok {
+		return ErrNotBatchAllocator
+	}
+
+	_, err := fmt.Fprintf(
+		w,
+		"buckets: %d, reserved: %d, used: %d, live: %d, ptrs: %d, fragmentation: %.2f\n",
+		len(stats.Buckets),
+		stats.ReservedBytes,
+		stats.UsedBytes,
+		stats.LiveBytes,
+		stats.LivePtrs,
+		stats.Fragmentation,
+	)
+	if err != nil {
+		return err
+	}
+
+	for i, b := range stats.Buckets {
+		_, err := fmt.Fprintf(
+			w,
+			"  bucket %d: size: %d, offset: %d, live: %d, ptrs: %d\n",
+			i,
+			b.Size,
+			b.Offset,
+			b.LiveBytes,
+			b.Ptrs,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getBatchAllocator returns the BatchAllocator state of alloc,
+// allocators are recognized by their alloc function so the state of other allocators is never read.
+func getBatchAllocator(alloc allocator.Allocator) (*BatchAllocator, bool) {
+	if !alloc.HasAllocFunc(batchAllocatorAlloc) {
+		return nil, false
+	}
+
+	return (*BatchAllocator)(alloc.Pointer()), true
+}
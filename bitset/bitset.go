@@ -0,0 +1,269 @@
+// bitset provides BitSet, a set of non-negative integers stored as bits in manually managed uint64 words.
+// It uses one bit per value instead of one byte like Vector[bool], and grows on demand.
+package bitset
+
+import (
+	"fmt"
+	"iter"
+	"math/bits"
+
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/vector"
+)
+
+const wordSize = 64
+
+// BitSet a growable set of bits, all bits are initially clear
+type BitSet struct {
+	words *vector.Vector[uint64]
+	alloc allocator.Allocator
+}
+
+// New creates a new empty BitSet
+func New(alloc allocator.Allocator) *BitSet {
+	b := allocator.Alloc[BitSet](alloc)
+	b.words = vector.New[uint64](alloc)
+	b.alloc = alloc
+	return b
+}
+
+// Len returns the number of bits the BitSet can hold without growing,
+// bits beyond it are clear.
+func (b *BitSet) Len() int {
+	return b.words.Len() * wordSize
+}
+
+// Set sets bit i, grows if needed.
+func (b *BitSet) Set(i int) {
+	checkIndex(i, "set")
+	b.grow(i + 1)
+	*b.words.AtPtr(i / wordSize) |= 1 << (i % wordSize)
+}
+
+// Clear clears bit i.
+func (b *BitSet) Clear(i int) {
+	checkIndex(i, "clear")
+	if i >= b.Len() {
+		return
+	}
+	*b.words.AtPtr(i / wordSize) &^= 1 << (i % wordSize)
+}
+
+// Flip flips bit i, grows if needed.
+func (b *BitSet) Flip(i int) {
+	checkIndex(i, "flip")
+	b.grow(i + 1)
+	*b.words.AtPtr(i / wordSize) ^= 1 << (i % wordSize)
+}
+
+// Test checks if bit i is set.
+func (b *BitSet) Test(i int) bool {
+	checkIndex(i, "test")
+	if i >= b.Len() {
+		return false
+	}
+	return b.words.At(i/wordSize)&(1<<(i%wordSize)) != 0
+}
+
+// SetRange sets the bits in the range [from, to), grows if needed.
+func (b *BitSet) SetRange(from, to int) {
+	checkRange(from, to, "set")
+	b.grow(to)
+	b.applyRange(from, to, func(word *uint64, mask uint64) { *word |= mask })
+}
+
+// ClearRange clears the bits in the range [from, to).
+func (b *BitSet) ClearRange(from, to int) {
+	checkRange(from, to, "clear")
+	to = min(to, b.Len())
+	b.applyRange(from, to, func(word *uint64, mask uint64) { *word &^= mask })
+}
+
+// FlipRange flips the bits in the range [from, to), grows if needed.
+func (b *BitSet) FlipRange(from, to int) {
+	checkRange(from, to, "flip")
+	b.grow(to)
+	b.applyRange(from, to, func(word *uint64, mask uint64) { *word ^= mask })
+}
+
+// applyRange calls f on every word in the range [from, to), with a mask of the bits in the range
+func (b *BitSet) applyRange(from, to int, f func(word *uint64, mask uint64)) {
+	for from < to {
+		wordIdx := from / wordSize
+		end := min(to, (wordIdx+1)*wordSize)
+
+		mask := ^uint64(0) << (from % wordSize)
+		if end%wordSize != 0 {
+			mask &= ^uint64(0) >> (wordSize - end%wordSize)
+		}
+
+		f(b.words.AtPtr(wordIdx), mask)
+		from = end
+	}
+}
+
+// Count returns the number of set bits.
+func (b *BitSet) Count() int {
+	count := 0
+	for _, word := range b.words.Slice() {
+		count += bits.OnesCount64(word)
+	}
+	return count
+}
+
+// NextSet returns the index of the first set bit at or after i,
+// returns false if there is no set bit after i.
+func (b *BitSet) NextSet(i int) (int, bool) {
+	checkIndex(i, "search from")
+
+	words := b.words.Slice()
+	wordIdx := i / wordSize
+	if wordIdx >= len(words) {
+		return 0, false
+	}
+
+	word := words[wordIdx] >> (i % wordSize)
+	if word != 0 {
+		return i + bits.TrailingZeros64(word), true
+	}
+
+	for wordIdx++; wordIdx < len(words); wordIdx++ {
+		if words[wordIdx] != 0 {
+			return wordIdx*wordSize + bits.TrailingZeros64(words[wordIdx]), true
+		}
+	}
+
+	return 0, false
+}
+
+// NextClear returns the index of the first clear bit at or after i,
+// there is always one because the bits beyond Len are clear.
+func (b *BitSet) NextClear(i int) int {
+	checkIndex(i, "search from")
+
+	words := b.words.Slice()
+	wordIdx := i / wordSize
+	if wordIdx >= len(words) {
+		return i
+	}
+
+	word := ^words[wordIdx] >> (i % wordSize)
+	if word != 0 {
+		return i + bits.TrailingZeros64(word)
+	}
+
+	for wordIdx++; wordIdx < len(words); wordIdx++ {
+		if words[wordIdx] != ^uint64(0) {
+			return wordIdx*wordSize + bits.TrailingZeros64(^words[wordIdx])
+		}
+	}
+
+	return len(words) * wordSize
+}
+
+// Iter returns an iterator over the indexes of the set bits in increasing order.
+func (b *BitSet) Iter() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for wordIdx, word := range b.words.Slice() {
+			for word != 0 {
+				if !yield(wordIdx*wordSize + bits.TrailingZeros64(word)) {
+					return
+				}
+				word &= word - 1 // clear the lowest set bit
+			}
+		}
+	}
+}
+
+// And keeps only the bits that are set in both b and other.
+func (b *BitSet) And(other *BitSet) {
+	words := b.words.Slice()
+	otherWords := other.words.Slice()
+	for i := range words {
+		if i < len(otherWords) {
+			words[i] &= otherWords[i]
+		} else {
+			words[i] = 0
+		}
+	}
+}
+
+// Or sets the bits that are set in other, grows if needed.
+func (b *BitSet) Or(other *BitSet) {
+	b.grow(other.Len())
+	words := b.words.Slice()
+	for i, word := range other.words.Slice() {
+		words[i] |= word
+	}
+}
+
+// Xor flips the bits that are set in other, grows if needed.
+func (b *BitSet) Xor(other *BitSet) {
+	b.grow(other.Len())
+	words := b.words.Slice()
+	for i, word := range other.words.Slice() {
+		words[i] ^= word
+	}
+}
+
+// AndNot clears the bits that are set in other.
+func (b *BitSet) AndNot(other *BitSet) {
+	words := b.words.Slice()
+	for i, word := range other.words.Slice() {
+		if i >= len(words) {
+			break
+		}
+		words[i] &^= word
+	}
+}
+
+// Reset clears all the bits, keeping the capacity.
+func (b *BitSet) Reset() {
+	clear(b.words.Slice())
+}
+
+// Clone returns a copy of the BitSet using the same allocator.
+func (b *BitSet) Clone() *BitSet {
+	return b.CloneInto(b.alloc)
+}
+
+// CloneInto returns a copy of the BitSet allocated using alloc.
+func (b *BitSet) CloneInto(alloc allocator.Allocator) *BitSet {
+	clone := allocator.Alloc[BitSet](alloc)
+	clone.words = b.words.CloneInto(alloc)
+	clone.alloc = alloc
+	return clone
+}
+
+// MoveTo copies the BitSet into alloc and frees the original,
+// the original BitSet must not be used afterwards.
+func (b *BitSet) MoveTo(alloc allocator.Allocator) *BitSet {
+	moved := b.CloneInto(alloc)
+	b.Free()
+	return moved
+}
+
+// Free frees the BitSet
+func (b *BitSet) Free() {
+	b.words.Free()
+	allocator.Free(b.alloc, b)
+}
+
+// grow makes sure the BitSet can hold n bits, new bits are clear
+func (b *BitSet) grow(n int) {
+	if words := (n + wordSize - 1) / wordSize; words > b.words.Len() {
+		b.words.Resize(words)
+	}
+}
+
+func checkIndex(i int, op string) {
+	if i < 0 {
+		panic(fmt.Sprintf("cannot %s bit %d", op, i))
+	}
+}
+
+func checkRange(from, to int, op string) {
+	if from < 0 || from > to {
+		panic(fmt.Sprintf("cannot %s bits in range [%d:%d]", op, from, to))
+	}
+}
@@ -0,0 +1,182 @@
+package bitset_test
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/joetifa2003/mm-go/bitset"
+	"github.com/joetifa2003/mm-go/mmtest"
+)
+
+func TestBitSet(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	b := bitset.New(alloc)
+	defer b.Free()
+
+	assert.False(b.Test(1000))
+
+	b.Set(3)
+	b.Set(64)
+	b.Set(200)
+	assert.Equal(256, b.Len())
+	assert.True(b.Test(3))
+	assert.True(b.Test(64))
+	assert.False(b.Test(4))
+	assert.Equal(3, b.Count())
+	assert.Equal([]int{3, 64, 200}, slices.Collect(b.Iter()))
+
+	b.Clear(64)
+	b.Clear(10000) // does nothing
+	b.Flip(3)
+	b.Flip(5)
+	assert.Equal([]int{5, 200}, slices.Collect(b.Iter()))
+
+	next, ok := b.NextSet(6)
+	assert.True(ok)
+	assert.Equal(200, next)
+	_, ok = b.NextSet(201)
+	assert.False(ok)
+	_, ok = b.NextSet(5000)
+	assert.False(ok)
+
+	b.Reset()
+	assert.Equal(0, b.Count())
+	assert.Equal(256, b.Len())
+
+	assert.Panics(func() {
+		b.Set(-1)
+	})
+	assert.Panics(func() {
+		b.SetRange(5, 4)
+	})
+}
+
+func TestBitSetRange(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	b := bitset.New(alloc)
+	defer b.Free()
+
+	b.SetRange(10, 140)
+	assert.Equal(130, b.Count())
+	assert.False(b.Test(9))
+	assert.True(b.Test(10))
+	assert.True(b.Test(139))
+	assert.False(b.Test(140))
+
+	b.ClearRange(60, 70)
+	assert.Equal(120, b.Count())
+	assert.Equal(60, b.NextClear(10))
+	next, _ := b.NextSet(60)
+	assert.Equal(70, next)
+
+	b.FlipRange(0, 192)
+	assert.Equal(192-120, b.Count())
+	assert.Equal(10, b.NextClear(0))
+	assert.Equal(192, b.NextClear(140))
+	assert.Equal(300, b.NextClear(300))
+
+	b.ClearRange(0, 1000)
+	assert.Equal(0, b.Count())
+
+	b.SetRange(0, 128)
+	assert.Equal(128, b.NextClear(0))
+}
+
+func TestBitSetAlgebra(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	newSet := func(values ...int) *bitset.BitSet {
+		b := bitset.New(alloc)
+		for _, v := range values {
+			b.Set(v)
+		}
+		return b
+	}
+
+	a := newSet(1, 2, 100)
+	defer a.Free()
+	small := newSet(2, 3)
+	defer small.Free()
+
+	and := a.Clone()
+	defer and.Free()
+	and.And(small)
+	assert.Equal([]int{2}, slices.Collect(and.Iter()))
+
+	or := small.Clone()
+	defer or.Free()
+	or.Or(a)
+	assert.Equal([]int{1, 2, 3, 100}, slices.Collect(or.Iter()))
+
+	xor := small.Clone()
+	defer xor.Free()
+	xor.Xor(a)
+	assert.Equal([]int{1, 3, 100}, slices.Collect(xor.Iter()))
+
+	andNot := a.Clone()
+	defer andNot.Free()
+	andNot.AndNot(small)
+	assert.Equal([]int{1, 100}, slices.Collect(andNot.Iter()))
+}
+
+func TestBitSetRandom(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	b := bitset.New(alloc)
+	defer b.Free()
+
+	const size = 1000
+	expected := make([]bool, size)
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 500 {
+		from := rng.IntN(size)
+		to := from + rng.IntN(size-from+1)
+
+		switch rng.IntN(4) {
+		case 0:
+			b.SetRange(from, to)
+			for i := from; i < to; i++ {
+				expected[i] = true
+			}
+		case 1:
+			b.ClearRange(from, to)
+			for i := from; i < to; i++ {
+				expected[i] = false
+			}
+		case 2:
+			b.FlipRange(from, to)
+			for i := from; i < to; i++ {
+				expected[i] = !expected[i]
+			}
+		case 3:
+			b.Flip(from)
+			expected[from] = !expected[from]
+		}
+	}
+
+	count := 0
+	for i, set := range expected {
+		assert.Equal(set, b.Test(i), "bit %d", i)
+		if set {
+			count++
+		}
+
+		nextSet, ok := b.NextSet(i)
+		expectedNext := slices.Index(expected[i:], true)
+		assert.Equal(expectedNext != -1, ok)
+		if ok {
+			assert.Equal(i+expectedNext, nextSet)
+		}
+	}
+	assert.Equal(count, b.Count())
+}
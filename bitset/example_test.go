@@ -0,0 +1,32 @@
+package bitset_test
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/bitset"
+)
+
+func Example() {
+	alloc := allocator.NewC()
+	defer alloc.Destroy()
+
+	primes := bitset.New(alloc)
+	defer primes.Free()
+
+	// sieve of eratosthenes
+	primes.SetRange(2, 30)
+	for i := range primes.Iter() {
+		for j := i * i; j < 30; j += i {
+			primes.Clear(j)
+		}
+	}
+
+	fmt.Println(slices.Collect(primes.Iter()))
+	fmt.Println("count:", primes.Count())
+
+	// Output:
+	// [2 3 5 7 11 13 17 19 23 29]
+	// count: 10
+}
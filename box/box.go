@@ -0,0 +1,116 @@
+// box provides Box, an owned pointer to manually managed memory.
+// A Box records the allocator it was allocated from, so ownership can be transferred between components explicitly using Move,
+// and the value is freed using the right allocator.
+// Building with the mmdebug build tag makes boxes detect use after free and double free across copies of the same Box.
+package box
+
+import (
+	"unsafe"
+
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/internal/debug"
+)
+
+const emptyBoxMsg = "cannot use an empty box (moved, taken or freed)"
+
+// Box is an owned pointer to T allocated from an allocator.
+// Copying a Box doesn't copy the value, use Move to transfer ownership.
+type Box[T any] struct {
+	ptr   *T `mm:"manual"`
+	alloc allocator.Allocator
+}
+
+// New allocates T using alloc, and initialize it with value
+func New[T any](alloc allocator.Allocator, value T) Box[T] {
+	ptr := allocator.Alloc[T](alloc)
+	*ptr = value
+	debug.Track(unsafe.Pointer(ptr))
+
+	return Box[T]{ptr: ptr, alloc: alloc}
+}
+
+// Get returns a pointer to the boxed value,
+// panics if the box is empty.
+// CAUTION: the pointer must not outlive the box
+func (b *Box[T]) Get() *T {
+	b.check()
+	return b.ptr
+}
+
+// Take moves the value out of the box and frees the box memory,
+// the box becomes empty.
+func (b *Box[T]) Take() T {
+	b.check()
+
+	value := *b.ptr
+	b.Free()
+
+	return value
+}
+
+// Move transfers ownership of the value to a new box,
+// the box becomes empty.
+func (b *Box[T]) Move() Box[T] {
+	b.check()
+
+	moved := *b
+	b.ptr = nil
+
+	return moved
+}
+
+// Clone returns a new box with a copy of the value using the same allocator.
+// The value is copied shallowly, pass cloneElem to deep copy it.
+func (b *Box[T]) Clone(cloneElem ...func(T) T) Box[T] {
+	return b.CloneInto(b.alloc, cloneElem...)
+}
+
+// CloneInto returns a new box with a copy of the value allocated using alloc.
+// The value is copied shallowly, pass cloneElem to deep copy it.
+func (b *Box[T]) CloneInto(alloc allocator.Allocator, cloneElem ...func(T) T) Box[T] {
+	b.check()
+
+	value := *b.ptr
+	if len(cloneElem) > 0 {
+		value = cloneElem[0](value)
+	}
+
+	return New(alloc, value)
+}
+
+// MoveTo copies the value into a new box allocated using alloc and frees the original,
+// the box becomes empty.
+func (b *Box[T]) MoveTo(alloc allocator.Allocator) Box[T] {
+	moved := b.CloneInto(alloc)
+	b.Free()
+	return moved
+}
+
+// IsEmpty checks if the box was moved, taken or freed
+func (b *Box[T]) IsEmpty() bool {
+	return b.ptr == nil
+}
+
+// Allocator returns the allocator used by the box
+func (b *Box[T]) Allocator() allocator.Allocator {
+	return b.alloc
+}
+
+// Free frees the boxed value, calling Free on an empty box does nothing.
+func (b *Box[T]) Free() {
+	if b.ptr == nil {
+		return
+	}
+
+	debug.Untrack(unsafe.Pointer(b.ptr), "box")
+	allocator.Free(b.alloc, b.ptr)
+	b.ptr = nil
+}
+
+func (b *Box[T]) check() {
+	if b.ptr == nil {
+		panic(emptyBoxMsg)
+	}
+
+	debug.CheckLive(unsafe.Pointer(b.ptr), "box")
+}
@@ -0,0 +1,28 @@
+//go:build mmdebug
+
+package box_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/box"
+)
+
+func TestBoxUseAfterFree(t *testing.T) {
+	alloc := allocator.NewC()
+	assert := assert.New(t)
+
+	b := box.New(alloc, 15)
+	copied := b // copying a box doesn't transfer ownership
+	b.Free()
+
+	assert.Panics(func() {
+		copied.Get()
+	})
+	assert.Panics(func() {
+		copied.Free()
+	})
+}
@@ -0,0 +1,65 @@
+package box_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/joetifa2003/mm-go/box"
+	"github.com/joetifa2003/mm-go/mmtest"
+)
+
+func TestBox(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	b := box.New(alloc, 15)
+	defer b.Free()
+
+	assert.False(b.IsEmpty())
+	assert.Equal(15, *b.Get())
+
+	*b.Get() = 20
+	assert.Equal(20, *b.Get())
+
+	moved := b.Move()
+	defer moved.Free()
+
+	assert.True(b.IsEmpty())
+	assert.Equal(20, *moved.Get())
+	assert.Panics(func() {
+		b.Get()
+	})
+	assert.Panics(func() {
+		b.Move()
+	})
+
+	assert.Equal(20, moved.Take())
+	assert.True(moved.IsEmpty())
+	assert.Panics(func() {
+		moved.Take()
+	})
+
+	// Free is idempotent
+	moved.Free()
+	moved.Free()
+}
+
+func TestBoxClone(t *testing.T) {
+	scratch := mmtest.NewAllocator(t)
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	b := box.New(scratch, 15)
+
+	clone := b.Clone(func(i int) int { return i + 1 })
+	defer clone.Free()
+
+	moved := b.MoveTo(alloc)
+	defer moved.Free()
+
+	assert.True(b.IsEmpty())
+	assert.Equal(15, *moved.Get())
+	assert.Equal(16, *clone.Get())
+	assert.Equal(alloc.Pointer(), moved.Allocator().Pointer())
+}
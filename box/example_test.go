@@ -0,0 +1,38 @@
+package box_test
+
+import (
+	"fmt"
+
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/box"
+)
+
+type Config struct {
+	Workers int
+}
+
+type Server struct {
+	config box.Box[Config]
+}
+
+func (s *Server) Close() {
+	s.config.Free()
+}
+
+func Example() {
+	alloc := allocator.NewC()
+	defer alloc.Destroy()
+
+	config := box.New(alloc, Config{Workers: 4})
+	defer config.Free() // does nothing if the box was moved
+
+	server := Server{config: config.Move()} // ownership is transferred to the server
+	defer server.Close()
+
+	fmt.Println(config.IsEmpty())
+	fmt.Println(server.config.Get().Workers)
+
+	// Output:
+	// true
+	// 4
+}
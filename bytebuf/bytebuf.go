@@ -0,0 +1,213 @@
+// bytebuf provides Buffer, a manually managed byte buffer modeled on bytes.Buffer.
+// It implements io.Reader, io.Writer, io.ByteWriter, io.StringWriter, io.WriterTo and io.ReaderFrom,
+// so large payloads can be processed using the standard library without being stored on the go heap.
+package bytebuf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"unsafe"
+
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/vector"
+)
+
+// MinRead is the minimum slice size passed to a Read call by Buffer.ReadFrom.
+const MinRead = 512
+
+var errNegativeRead = errors.New("bytebuf: reader returned negative count from Read")
+
+// Buffer is a variable-sized buffer of bytes with Read and Write methods.
+// Bytes are read from the front of the buffer and written to the back.
+type Buffer struct {
+	data  *vector.Vector[byte]
+	off   int // Read offset, bytes before it are already read
+	alloc allocator.Allocator
+}
+
+// New creates a new empty Buffer
+func New(alloc allocator.Allocator) *Buffer {
+	buf := allocator.Alloc[Buffer](alloc)
+	buf.data = vector.New[byte](alloc)
+	buf.alloc = alloc
+	return buf
+}
+
+// Bytes returns the unread portion of the buffer.
+// CAUTION: the slice represents manually managed memory, don't append to it,
+// and it's only valid until the next buffer modification or Free
+func (b *Buffer) Bytes() []byte {
+	return b.data.Slice()[b.off:]
+}
+
+// String returns the unread portion of the buffer as a go string
+func (b *Buffer) String() string {
+	return string(b.Bytes())
+}
+
+// Len returns the number of unread bytes
+func (b *Buffer) Len() int {
+	return b.data.Len() - b.off
+}
+
+// Cap returns the capacity of the underlying memory
+func (b *Buffer) Cap() int {
+	return b.data.Cap()
+}
+
+// Reset empties the buffer, keeping its capacity
+func (b *Buffer) Reset() {
+	b.data.Clear()
+	b.off = 0
+}
+
+// Truncate discards all but the first n unread bytes,
+// panics if n is negative or greater than the length of the buffer.
+func (b *Buffer) Truncate(n int) {
+	if n == 0 {
+		b.Reset()
+		return
+	}
+
+	if n < 0 || n > b.Len() {
+		panic(fmt.Sprintf("cannot truncate a buffer with length %d to %d", b.Len(), n))
+	}
+
+	b.data.Truncate(b.off + n)
+}
+
+// Grow grows the buffer's capacity, if necessary, to guarantee space for another n bytes.
+func (b *Buffer) Grow(n int) {
+	if n < 0 {
+		panic(fmt.Sprintf("cannot grow a buffer by %d", n))
+	}
+
+	b.grow(n)
+}
+
+// grow makes room for n more bytes, reusing the space of the bytes already read before allocating,
+// the capacity is at least doubled when it allocates so writing byte by byte doesn't realloc every time.
+func (b *Buffer) grow(n int) {
+	if b.off > 0 && b.off == b.data.Len() {
+		b.Reset()
+	}
+
+	if b.off > 0 && b.data.Len()+n > b.data.Cap() {
+		b.data.RemoveRange(0, b.off)
+		b.off = 0
+	}
+
+	if b.data.Len()+n > b.data.Cap() {
+		b.data.Reserve(max(2*b.data.Cap(), b.data.Len()+n) - b.data.Len())
+	}
+}
+
+// overlaps checks if p points into the memory of the buffer
+func (b *Buffer) overlaps(p []byte) bool {
+	data := b.data.Slice()
+	if len(p) == 0 || cap(data) == 0 {
+		return false
+	}
+
+	start := uintptr(unsafe.Pointer(unsafe.SliceData(data)))
+	pStart := uintptr(unsafe.Pointer(unsafe.SliceData(p)))
+	return pStart < start+uintptr(cap(data)) && pStart+uintptr(len(p)) > start
+}
+
+// Write appends the contents of p to the buffer, it always returns len(p), nil.
+func (b *Buffer) Write(p []byte) (n int, err error) {
+	// the grow moves or frees the bytes if p points into the buffer, so copy them first
+	if b.data.Len()+len(p) > b.data.Cap() && b.overlaps(p) {
+		p = slices.Clone(p)
+	}
+
+	b.grow(len(p))
+	b.data.Extend(p)
+	return len(p), nil
+}
+
+// WriteString appends the contents of s to the buffer, it always returns len(s), nil.
+func (b *Buffer) WriteString(s string) (n int, err error) {
+	return b.Write(unsafe.Slice(unsafe.StringData(s), len(s)))
+}
+
+// WriteByte appends the byte c to the buffer, it always returns nil.
+func (b *Buffer) WriteByte(c byte) error {
+	b.grow(1)
+	b.data.Push(c)
+	return nil
+}
+
+// Read reads the next len(p) bytes from the buffer or until the buffer is drained,
+// it returns io.EOF if the buffer has no data to return and len(p) is not zero.
+func (b *Buffer) Read(p []byte) (n int, err error) {
+	if b.Len() == 0 {
+		b.Reset()
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+
+	n = copy(p, b.Bytes())
+	b.off += n
+	return n, nil
+}
+
+// WriteTo writes the unread bytes to w until the buffer is drained or an error occurs.
+func (b *Buffer) WriteTo(w io.Writer) (n int64, err error) {
+	if b.Len() == 0 {
+		b.Reset()
+		return 0, nil
+	}
+
+	unread := b.Len()
+	m, err := w.Write(b.Bytes())
+	if m > unread {
+		panic("bytebuf: invalid Write count")
+	}
+	b.off += m
+	n = int64(m)
+	if err != nil {
+		return n, err
+	}
+	if m != unread {
+		return n, io.ErrShortWrite
+	}
+
+	b.Reset()
+	return n, nil
+}
+
+// ReadFrom reads data from r until io.EOF and appends it to the buffer, growing it as needed.
+// Any error except io.EOF encountered during the read is returned.
+func (b *Buffer) ReadFrom(r io.Reader) (n int64, err error) {
+	for {
+		b.grow(MinRead)
+
+		// read directly into the spare capacity, without clearing it first
+		data := b.data.Slice()
+		spare := data[len(data):cap(data)]
+		read, err := r.Read(spare)
+		if read < 0 {
+			return n, errNegativeRead
+		}
+		b.data.Extend(spare[:read]) // the bytes are already in place, this only sets the length
+		n += int64(read)
+
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+// Free frees the buffer
+func (b *Buffer) Free() {
+	b.data.Free()
+	allocator.Free(b.alloc, b)
+}
@@ -0,0 +1,228 @@
+package bytebuf_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/bytebuf"
+	"github.com/joetifa2003/mm-go/mmtest"
+)
+
+var (
+	_ io.Reader       = (*bytebuf.Buffer)(nil)
+	_ io.Writer       = (*bytebuf.Buffer)(nil)
+	_ io.ByteWriter   = (*bytebuf.Buffer)(nil)
+	_ io.StringWriter = (*bytebuf.Buffer)(nil)
+	_ io.WriterTo     = (*bytebuf.Buffer)(nil)
+	_ io.ReaderFrom   = (*bytebuf.Buffer)(nil)
+)
+
+func TestBufferReadWrite(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	buf := bytebuf.New(alloc)
+	defer buf.Free()
+
+	buf.WriteString("hello")
+	buf.WriteByte(' ')
+	buf.Write([]byte("world"))
+	assert.Equal(11, buf.Len())
+	assert.Equal("hello world", buf.String())
+
+	p := make([]byte, 6)
+	n, err := buf.Read(p)
+	assert.NoError(err)
+	assert.Equal(6, n)
+	assert.Equal("hello ", string(p))
+	assert.Equal("world", string(buf.Bytes()))
+
+	buf.Truncate(3)
+	assert.Equal("wor", buf.String())
+	assert.Panics(func() {
+		buf.Truncate(4)
+	})
+
+	n, err = buf.Read(p)
+	assert.NoError(err)
+	assert.Equal(3, n)
+
+	n, err = buf.Read(p)
+	assert.Equal(io.EOF, err)
+	assert.Equal(0, n)
+	n, err = buf.Read(nil)
+	assert.NoError(err)
+	assert.Equal(0, n)
+}
+
+func TestBufferGrow(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	buf := bytebuf.New(alloc)
+	defer buf.Free()
+
+	buf.Grow(64)
+	assert.GreaterOrEqual(buf.Cap(), 64)
+	assert.Equal(0, buf.Len())
+
+	buf.WriteString(strings.Repeat("a", 64))
+	capacity := buf.Cap()
+
+	// the space of read bytes is reused instead of growing
+	io.CopyN(io.Discard, buf, 32)
+	buf.WriteString(strings.Repeat("b", 32))
+	assert.Equal(capacity, buf.Cap())
+	assert.Equal(strings.Repeat("a", 32)+strings.Repeat("b", 32), buf.String())
+
+	buf.Reset()
+	assert.Equal(0, buf.Len())
+	assert.Equal(capacity, buf.Cap())
+
+	assert.Panics(func() {
+		buf.Grow(-1)
+	})
+}
+
+func TestBufferReallocs(t *testing.T) {
+	assert := assert.New(t)
+
+	alloc, counts := newCountingAllocator(t)
+
+	buf := bytebuf.New(alloc)
+	defer buf.Free()
+
+	for range 10_000 {
+		buf.WriteByte('a')
+	}
+	assert.Equal(10_000, buf.Len())
+	assert.LessOrEqual(counts.reallocs, 15)
+
+	buf.Reset()
+	counts.reallocs = 0
+	n, err := buf.ReadFrom(bytes.NewReader(make([]byte, 1<<20)))
+	assert.NoError(err)
+	assert.Equal(int64(1<<20), n)
+	assert.LessOrEqual(counts.reallocs, 10)
+}
+
+// chunkedReader reads at most size bytes at a time
+type chunkedReader struct {
+	r    io.Reader
+	size int
+}
+
+func (r chunkedReader) Read(p []byte) (int, error) {
+	return r.r.Read(p[:min(len(p), r.size)])
+}
+
+func TestBufferReadFromChunked(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	buf := bytebuf.New(alloc)
+	defer buf.Free()
+
+	payload := strings.Repeat("0123456789", 10_000)
+	n, err := buf.ReadFrom(chunkedReader{strings.NewReader(payload), 7})
+	assert.NoError(err)
+	assert.Equal(int64(len(payload)), n)
+	assert.Equal(payload, buf.String())
+}
+
+func BenchmarkBufferReadFrom(b *testing.B) {
+	alloc := allocator.NewC()
+	defer alloc.Destroy()
+
+	payload := make([]byte, 16<<20)
+	b.SetBytes(int64(len(payload)))
+	for range b.N {
+		buf := bytebuf.New(alloc)
+		buf.ReadFrom(chunkedReader{bytes.NewReader(payload), bytebuf.MinRead})
+		buf.Free()
+	}
+}
+
+func TestBufferWriteOverlap(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	buf := bytebuf.New(alloc)
+	defer buf.Free()
+
+	buf.WriteString("abc")
+	buf.Write(buf.Bytes()) // grows, the bytes are freed
+	assert.Equal("abcabc", buf.String())
+
+	io.CopyN(io.Discard, buf, 2)
+	buf.Write(buf.Bytes()) // the read bytes are discarded, shifting the unread ones
+	assert.Equal("cabccabc", buf.String())
+}
+
+func TestBufferReadFromWriteTo(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	buf := bytebuf.New(alloc)
+	defer buf.Free()
+
+	payload := strings.Repeat("mm-go ", 1000)
+	n, err := buf.ReadFrom(strings.NewReader(payload))
+	assert.NoError(err)
+	assert.Equal(int64(len(payload)), n)
+	assert.Equal(payload, buf.String())
+
+	var out bytes.Buffer
+	n, err = buf.WriteTo(&out)
+	assert.NoError(err)
+	assert.Equal(int64(len(payload)), n)
+	assert.Equal(payload, out.String())
+	assert.Equal(0, buf.Len())
+
+	n, err = io.Copy(buf, strings.NewReader("copied"))
+	assert.NoError(err)
+	assert.Equal(int64(6), n)
+	assert.Equal("copied", buf.String())
+}
+
+// counts is stored in C memory, so the allocator that uses it as its state
+// can be copied into the buffer like any other allocator
+type counts struct {
+	c        allocator.Allocator
+	reallocs int
+}
+
+// newCountingAllocator returns a C allocator that counts its reallocs
+func newCountingAllocator(t testing.TB) (allocator.Allocator, *counts) {
+	c := allocator.NewC()
+	state := allocator.Alloc[counts](c)
+	state.c = c
+	t.Cleanup(func() {
+		allocator.Free(c, state)
+		c.Destroy()
+	})
+
+	return allocator.NewAllocator(unsafe.Pointer(state), countingAlloc, countingFree, countingRealloc, countingDestroy), state
+}
+
+func countingAlloc(a unsafe.Pointer, size int) unsafe.Pointer {
+	return (*counts)(a).c.Alloc(size)
+}
+
+func countingFree(a unsafe.Pointer, ptr unsafe.Pointer) {
+	(*counts)(a).c.Free(ptr)
+}
+
+func countingRealloc(a unsafe.Pointer, ptr unsafe.Pointer, size int) unsafe.Pointer {
+	state := (*counts)(a)
+	state.reallocs++
+	return state.c.Realloc(ptr, size)
+}
+
+func countingDestroy(a unsafe.Pointer) {}
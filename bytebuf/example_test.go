@@ -0,0 +1,26 @@
+package bytebuf_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/bytebuf"
+)
+
+func Example() {
+	alloc := allocator.NewC()
+	defer alloc.Destroy()
+
+	buf := bytebuf.New(alloc)
+	defer buf.Free()
+
+	io.Copy(buf, strings.NewReader("hello "))
+	fmt.Fprintf(buf, "%s!\n", "world")
+	buf.WriteTo(os.Stdout)
+
+	// Output:
+	// hello world!
+}
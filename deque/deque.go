@@ -0,0 +1,287 @@
+// deque provides Deque, a double-ended queue backed by a growable circular buffer.
+// A Deque can also have a fixed capacity, to be used as a ring buffer that overwrites its oldest values or rejects new ones when full.
+package deque
+
+import (
+	"fmt"
+	"iter"
+	"unsafe"
+
+	"github.com/joetifa2003/mm-go"
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/internal/debug"
+)
+
+var popEmptyMsg = "cannot pop empty deque"
+
+// FullPolicy decides what happens when pushing to a full fixed capacity Deque
+type FullPolicy int
+
+const (
+	// Reject doesn't push the value, and the push returns false
+	Reject FullPolicy = iota
+	// Overwrite removes the value at the other end to make room for the pushed value
+	Overwrite
+)
+
+// Deque a double-ended queue backed by a circular buffer
+type Deque[T any] struct {
+	data   []T `mm:"manual"`
+	head   int // Index of the front value in data
+	len    int
+	fixed  bool
+	policy FullPolicy
+	alloc  allocator.Allocator
+}
+
+type dequeOptions struct {
+	capacity int
+	fixed    bool
+	policy   FullPolicy
+}
+
+type DequeOption func(opts *dequeOptions)
+
+// WithCapacity Option to specify the initial capacity of the Deque
+func WithCapacity(capacity int) DequeOption {
+	return func(opts *dequeOptions) {
+		opts.capacity = capacity
+	}
+}
+
+// WithFixedCapacity Option to make the Deque a ring buffer that never grows beyond capacity,
+// policy decides what happens when pushing to a full Deque.
+func WithFixedCapacity(capacity int, policy FullPolicy) DequeOption {
+	return func(opts *dequeOptions) {
+		opts.capacity = capacity
+		opts.fixed = true
+		opts.policy = policy
+	}
+}
+
+// New creates a new empty Deque and applies optional configuration using DequeOption
+func New[T any](alloc allocator.Allocator, options ...DequeOption) *Deque[T] {
+	opts := dequeOptions{capacity: 8}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	if opts.capacity < 1 {
+		panic(fmt.Sprintf("cannot create a deque with capacity %d", opts.capacity))
+	}
+
+	mm.CheckGoPointers[T]()
+
+	dq := allocator.Alloc[Deque[T]](alloc)
+	dq.data = allocator.AllocMany[T](alloc, opts.capacity)
+	dq.fixed = opts.fixed
+	dq.policy = opts.policy
+	dq.alloc = alloc
+	debug.Track(unsafe.Pointer(dq))
+
+	return dq
+}
+
+// PushBack pushes value T to the back of the Deque, grows if needed.
+// returns false if the Deque has a fixed capacity, is full and uses the Reject policy
+func (dq *Deque[T]) PushBack(value T) bool {
+	dq.check()
+
+	if !dq.makeRoom(dq.PopFront) {
+		return false
+	}
+
+	dq.data[dq.index(dq.len)] = value
+	dq.len++
+	return true
+}
+
+// PushFront pushes value T to the front of the Deque, grows if needed.
+// returns false if the Deque has a fixed capacity, is full and uses the Reject policy
+func (dq *Deque[T]) PushFront(value T) bool {
+	dq.check()
+
+	if !dq.makeRoom(dq.PopBack) {
+		return false
+	}
+
+	dq.head = dq.index(len(dq.data) - 1)
+	dq.data[dq.head] = value
+	dq.len++
+	return true
+}
+
+// makeRoom makes room for one value, by growing or by popping from the other end using pop
+func (dq *Deque[T]) makeRoom(pop func() T) bool {
+	if dq.len < len(dq.data) {
+		return true
+	}
+
+	if !dq.fixed {
+		dq.grow()
+		return true
+	}
+
+	if dq.policy == Overwrite {
+		pop()
+		return true
+	}
+
+	return false
+}
+
+// grow doubles the capacity, and moves the wrapped values after the old end
+func (dq *Deque[T]) grow() {
+	oldCap := len(dq.data)
+	dq.data = allocator.Realloc(dq.alloc, dq.data, oldCap*2)
+
+	if wrapped := dq.head + dq.len - oldCap; wrapped > 0 {
+		copy(dq.data[oldCap:], dq.data[:wrapped])
+	}
+}
+
+// PopBack pops and returns value T from the back of the Deque.
+func (dq *Deque[T]) PopBack() T {
+	dq.check()
+
+	if dq.len == 0 {
+		panic(popEmptyMsg)
+	}
+
+	dq.len--
+	return dq.data[dq.index(dq.len)]
+}
+
+// PopFront pops and returns value T from the front of the Deque.
+func (dq *Deque[T]) PopFront() T {
+	dq.check()
+
+	if dq.len == 0 {
+		panic(popEmptyMsg)
+	}
+
+	value := dq.data[dq.head]
+	dq.head = dq.index(1)
+	dq.len--
+	return value
+}
+
+// Front returns the value at the front of the Deque without removing it.
+func (dq *Deque[T]) Front() T {
+	return dq.At(0)
+}
+
+// Back returns the value at the back of the Deque without removing it.
+func (dq *Deque[T]) Back() T {
+	return dq.At(dq.Len() - 1)
+}
+
+// At gets value T at idx, the front of the Deque is at index 0.
+func (dq *Deque[T]) At(idx int) T {
+	return *dq.AtPtr(idx)
+}
+
+// AtPtr gets a pointer to value T at idx, the front of the Deque is at index 0.
+func (dq *Deque[T]) AtPtr(idx int) *T {
+	dq.check()
+
+	if idx < 0 || idx >= dq.len {
+		panic(fmt.Sprintf("cannot index %d in a deque with length %d", idx, dq.len))
+	}
+
+	return &dq.data[dq.index(idx)]
+}
+
+// Len gets the number of values in the Deque
+func (dq *Deque[T]) Len() int {
+	dq.check()
+	return dq.len
+}
+
+// Cap gets the capacity of the Deque
+func (dq *Deque[T]) Cap() int {
+	dq.check()
+	return len(dq.data)
+}
+
+// IsFull checks if the Deque would grow, overwrite or reject on the next push
+func (dq *Deque[T]) IsFull() bool {
+	dq.check()
+	return dq.len == len(dq.data)
+}
+
+// Clear removes all the values from the Deque, keeping its capacity.
+func (dq *Deque[T]) Clear() {
+	dq.check()
+	dq.head = 0
+	dq.len = 0
+}
+
+// Iter returns an iterator over the values from front to back.
+func (dq *Deque[T]) Iter() iter.Seq2[int, T] {
+	dq.check()
+
+	return func(yield func(int, T) bool) {
+		for i := 0; i < dq.len; i++ {
+			if !yield(i, dq.data[dq.index(i)]) {
+				return
+			}
+		}
+	}
+}
+
+// Clone returns a copy of the Deque using the same allocator.
+// Values are copied shallowly, pass cloneElem to deep copy them.
+func (dq *Deque[T]) Clone(cloneElem ...func(T) T) *Deque[T] {
+	return dq.CloneInto(dq.alloc, cloneElem...)
+}
+
+// CloneInto returns a copy of the Deque allocated using alloc.
+// Values are copied shallowly, pass cloneElem to deep copy them.
+func (dq *Deque[T]) CloneInto(alloc allocator.Allocator, cloneElem ...func(T) T) *Deque[T] {
+	dq.check()
+
+	clone := New[T](alloc, WithCapacity(len(dq.data)))
+	clone.fixed = dq.fixed
+	clone.policy = dq.policy
+	for _, value := range dq.Iter() {
+		if len(cloneElem) > 0 {
+			value = cloneElem[0](value)
+		}
+		clone.data[clone.len] = value
+		clone.len++
+	}
+
+	return clone
+}
+
+// MoveTo copies the Deque into alloc and frees the original,
+// the values are moved as is and the original Deque must not be used afterwards.
+func (dq *Deque[T]) MoveTo(alloc allocator.Allocator) *Deque[T] {
+	moved := dq.CloneInto(alloc)
+	dq.Free()
+	return moved
+}
+
+// Free frees the Deque
+func (dq *Deque[T]) Free() {
+	debug.Untrack(unsafe.Pointer(dq), "deque")
+
+	alloc := dq.alloc
+	data := dq.data
+	debug.Poison(unsafe.Pointer(unsafe.SliceData(data)), len(data)*mm.SizeOf[T]())
+	debug.Poison(unsafe.Pointer(dq), mm.SizeOf[Deque[T]]())
+
+	allocator.FreeMany(alloc, data)
+	allocator.Free(alloc, dq)
+}
+
+// index returns the index in data of the value at offset from the front
+func (dq *Deque[T]) index(offset int) int {
+	return (dq.head + offset) % len(dq.data)
+}
+
+// check panics if the deque is freed, only in debug builds (mmdebug build tag)
+func (dq *Deque[T]) check() {
+	debug.CheckLive(unsafe.Pointer(dq), "deque")
+}
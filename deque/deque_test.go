@@ -0,0 +1,134 @@
+package deque_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/joetifa2003/mm-go/deque"
+	"github.com/joetifa2003/mm-go/mmtest"
+)
+
+func dequeToSlice[T any](dq *deque.Deque[T]) []T {
+	res := []T{}
+	for _, value := range dq.Iter() {
+		res = append(res, value)
+	}
+	return res
+}
+
+func TestDeque(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	dq := deque.New[int](alloc, deque.WithCapacity(2))
+	defer dq.Free()
+
+	dq.PushBack(2)
+	dq.PushBack(3)
+	dq.PushFront(1) // wraps around then grows
+	dq.PushFront(0)
+	dq.PushBack(4)
+
+	assert.Equal(5, dq.Len())
+	assert.Equal(8, dq.Cap())
+	assert.Equal([]int{0, 1, 2, 3, 4}, dequeToSlice(dq))
+	assert.Equal(0, dq.Front())
+	assert.Equal(4, dq.Back())
+	assert.Equal(2, dq.At(2))
+	assert.Panics(func() {
+		dq.At(5)
+	})
+	assert.Panics(func() {
+		dq.At(-1)
+	})
+
+	*dq.AtPtr(2) = 20
+	assert.Equal(0, dq.PopFront())
+	assert.Equal(4, dq.PopBack())
+	assert.Equal([]int{1, 20, 3}, dequeToSlice(dq))
+
+	dq.Clear()
+	assert.Equal(0, dq.Len())
+	assert.Panics(func() {
+		dq.PopFront()
+	})
+	assert.Panics(func() {
+		dq.PopBack()
+	})
+}
+
+func TestDequeFIFO(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	dq := deque.New[int](alloc, deque.WithCapacity(4))
+	defer dq.Free()
+
+	// keep the deque half full while the head moves around the buffer
+	next := 0
+	for i := range 100 {
+		dq.PushBack(i)
+		if dq.Len() > 3 {
+			assert.Equal(next, dq.PopFront())
+			next++
+		}
+	}
+	assert.Equal(4, dq.Cap())
+	assert.Equal([]int{97, 98, 99}, dequeToSlice(dq))
+}
+
+func TestDequeFixedCapacity(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	reject := deque.New[int](alloc, deque.WithFixedCapacity(3, deque.Reject))
+	defer reject.Free()
+
+	for i := range 3 {
+		assert.True(reject.PushBack(i))
+	}
+	assert.True(reject.IsFull())
+	assert.False(reject.PushBack(3))
+	assert.False(reject.PushFront(3))
+	assert.Equal([]int{0, 1, 2}, dequeToSlice(reject))
+
+	overwrite := deque.New[int](alloc, deque.WithFixedCapacity(3, deque.Overwrite))
+	defer overwrite.Free()
+
+	for i := range 5 {
+		assert.True(overwrite.PushBack(i))
+	}
+	assert.Equal([]int{2, 3, 4}, dequeToSlice(overwrite))
+	overwrite.PushFront(1)
+	assert.Equal([]int{1, 2, 3}, dequeToSlice(overwrite))
+	assert.Equal(3, overwrite.Cap())
+
+	assert.Panics(func() {
+		deque.New[int](alloc, deque.WithFixedCapacity(0, deque.Reject))
+	})
+}
+
+func TestDequeClone(t *testing.T) {
+	scratch := mmtest.NewAllocator(t)
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	dq := deque.New[int](scratch, deque.WithFixedCapacity(2, deque.Overwrite))
+	dq.PushBack(1)
+	dq.PushBack(2)
+	dq.PushBack(3)
+
+	clone := dq.Clone(func(i int) int { return i * 10 })
+	defer clone.Free()
+
+	moved := dq.MoveTo(alloc)
+	defer moved.Free()
+
+	assert.Equal([]int{2, 3}, dequeToSlice(moved))
+	assert.Equal([]int{20, 30}, dequeToSlice(clone))
+
+	// the fixed capacity mode is preserved
+	moved.PushBack(4)
+	assert.Equal([]int{3, 4}, dequeToSlice(moved))
+}
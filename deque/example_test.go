@@ -0,0 +1,51 @@
+package deque_test
+
+import (
+	"fmt"
+
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/deque"
+)
+
+func Example() {
+	alloc := allocator.NewC()
+	defer alloc.Destroy()
+
+	dq := deque.New[int](alloc)
+	defer dq.Free()
+
+	dq.PushBack(2)
+	dq.PushBack(3)
+	dq.PushFront(1)
+
+	for dq.Len() > 0 {
+		fmt.Println(dq.PopFront())
+	}
+
+	// Output:
+	// 1
+	// 2
+	// 3
+}
+
+func Example_ringBuffer() {
+	alloc := allocator.NewC()
+	defer alloc.Destroy()
+
+	// keep the last 3 values only
+	last := deque.New[int](alloc, deque.WithFixedCapacity(3, deque.Overwrite))
+	defer last.Free()
+
+	for i := range 10 {
+		last.PushBack(i)
+	}
+
+	for _, value := range last.Iter() {
+		fmt.Println(value)
+	}
+
+	// Output:
+	// 7
+	// 8
+	// 9
+}
@@ -13,3 +13,18 @@ func ExampleSizeOf() {
 	// 4
 	// 8
 }
+
+func ExampleHasGoPointers() {
+	type Point struct {
+		X, Y int
+	}
+	type Named struct {
+		Name string // the GC can't see strings stored in manually managed memory
+	}
+
+	fmt.Println(mm.HasGoPointers[Point]())
+	fmt.Println(mm.HasGoPointers[Named]())
+	// Output:
+	// false
+	// true
+}
@@ -0,0 +1,135 @@
+package mm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const modulePath = "github.com/joetifa2003/mm-go"
+
+var (
+	strictMode   atomic.Bool
+	allowedTypes sync.Map // reflect.Type -> struct{}
+	layoutCache  sync.Map // reflect.Type -> bool
+)
+
+// SetStrictMode enables or disables strict mode.
+// In strict mode allocating a type that contains go pointers (pointers, strings, slices, maps, channels, funcs or interfaces)
+// using allocator.Alloc, allocator.AllocMany or any of the containers panics,
+// because the GC doesn't scan manually managed memory and will collect the objects they point to.
+func SetStrictMode(enabled bool) {
+	strictMode.Store(enabled)
+}
+
+// StrictMode reports whether strict mode is enabled
+func StrictMode() bool {
+	return strictMode.Load()
+}
+
+// AllowType marks T as safe to store in manually managed memory even if it contains go pointers,
+// use it for pointers into manually managed memory, for example AllowType[*MyStruct]() if MyStruct is allocated using an allocator.
+// Pointers to mm-go types (vector, hashmap, etc...) are always allowed, but the types they are instantiated with are checked.
+func AllowType[T any]() {
+	allowedTypes.Store(reflect.TypeFor[T](), struct{}{})
+	layoutCache.Clear()
+}
+
+// HasGoPointers reports whether T contains pointers that are visible to the go GC,
+// types allowed using AllowType and pointers to mm-go types are not considered,
+// for example *vector.Vector[int] doesn't have go pointers but *vector.Vector[string] does.
+func HasGoPointers[T any]() bool {
+	t := reflect.TypeFor[T]()
+	if res, ok := layoutCache.Load(t); ok {
+		return res.(bool)
+	}
+
+	res := hasGoPointers(t, map[reflect.Type]bool{})
+	layoutCache.Store(t, res)
+	return res
+}
+
+// CheckGoPointers panics if strict mode is enabled and T contains go pointers,
+// it's called by allocators and containers before allocating T.
+func CheckGoPointers[T any]() {
+	if !strictMode.Load() {
+		return
+	}
+
+	if HasGoPointers[T]() {
+		panic(fmt.Sprintf("cannot allocate %s in manually managed memory because it contains go pointers, use mm.AllowType if it only points to manually managed memory", reflect.TypeFor[T]()))
+	}
+}
+
+func isAllowed(t reflect.Type) bool {
+	_, ok := allowedTypes.Load(t)
+	return ok
+}
+
+// isModuleType reports whether t is defined by mm-go, mm-go types are always allocated in manually managed memory,
+// so pointers to them are allowed, but their fields are still checked.
+func isModuleType(t reflect.Type) bool {
+	pkg := t.PkgPath()
+	return (pkg == modulePath || strings.HasPrefix(pkg, modulePath+"/")) && !strings.HasSuffix(pkg, "_test")
+}
+
+// hasGoPointers checks t recursively, visiting holds the structs being checked so recursive types terminate.
+// Struct fields tagged with mm:"manual" point into manually managed memory, only what they point to is checked,
+// and fields tagged with mm:"static" hold functions that must not be closures, so they are skipped.
+func hasGoPointers(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if isAllowed(t) {
+		return false
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if isAllowed(t.Elem()) {
+			return false
+		}
+		return !isModuleType(t.Elem()) || hasGoPointers(t.Elem(), visiting)
+	case reflect.UnsafePointer, reflect.String, reflect.Slice, reflect.Map,
+		reflect.Chan, reflect.Func, reflect.Interface:
+		return true
+	case reflect.Array:
+		return t.Len() > 0 && hasGoPointers(t.Elem(), visiting)
+	case reflect.Struct:
+		if visiting[t] {
+			return false
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		for i := range t.NumField() {
+			f := t.Field(i)
+			switch f.Tag.Get("mm") {
+			case "static":
+				continue
+			case "manual":
+				if pointeeHasGoPointers(f.Type, visiting) {
+					return true
+				}
+			default:
+				if hasGoPointers(f.Type, visiting) {
+					return true
+				}
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// pointeeHasGoPointers checks the manually managed memory a field tagged with mm:"manual" points to
+func pointeeHasGoPointers(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice:
+		return hasGoPointers(t.Elem(), visiting)
+	case reflect.UnsafePointer:
+		return false
+	default:
+		return hasGoPointers(t, visiting)
+	}
+}
@@ -0,0 +1,129 @@
+package mm_test
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/joetifa2003/mm-go"
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/box"
+	"github.com/joetifa2003/mm-go/hashmap"
+	"github.com/joetifa2003/mm-go/linkedlist"
+	"github.com/joetifa2003/mm-go/mmstring"
+	"github.com/joetifa2003/mm-go/mmtest"
+	"github.com/joetifa2003/mm-go/rc"
+	"github.com/joetifa2003/mm-go/typedarena"
+	"github.com/joetifa2003/mm-go/vector"
+)
+
+type plain struct {
+	a int
+	b [4]float32
+}
+
+type withString struct {
+	a    int
+	name string
+}
+
+type withVector struct {
+	v *vector.Vector[int]
+	s *mmstring.MMString
+}
+
+type manual struct {
+	a int
+}
+
+type withManualPtr struct {
+	p *manual
+}
+
+func TestHasGoPointers(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.False(mm.HasGoPointers[int]())
+	assert.False(mm.HasGoPointers[plain]())
+	assert.False(mm.HasGoPointers[[0]string]())
+	assert.False(mm.HasGoPointers[withVector]())
+	assert.False(mm.HasGoPointers[vector.Vector[int]]())
+	assert.False(mm.HasGoPointers[hashmap.Hashmap[int, *vector.Vector[int]]]())
+	assert.False(mm.HasGoPointers[linkedlist.LinkedList[int]]())
+	assert.False(mm.HasGoPointers[typedarena.TypedArena[int]]())
+	assert.False(mm.HasGoPointers[rc.Rc[int]]())
+	assert.False(mm.HasGoPointers[allocator.Allocator]())
+	assert.False(mm.HasGoPointers[hashmap.BytesKey]())
+
+	assert.True(mm.HasGoPointers[string]())
+	assert.True(mm.HasGoPointers[[]int]())
+	assert.True(mm.HasGoPointers[map[int]int]())
+	assert.True(mm.HasGoPointers[*int]())
+	assert.True(mm.HasGoPointers[unsafe.Pointer]())
+	assert.True(mm.HasGoPointers[any]())
+	assert.True(mm.HasGoPointers[func()]())
+	assert.True(mm.HasGoPointers[chan int]())
+	assert.True(mm.HasGoPointers[withString]())
+	assert.True(mm.HasGoPointers[[2]withString]())
+
+	// mm-go types are checked with the types they are instantiated with
+	assert.True(mm.HasGoPointers[vector.Vector[string]]())
+	assert.True(mm.HasGoPointers[*vector.Vector[string]]())
+	assert.True(mm.HasGoPointers[box.Box[map[int]int]]())
+	assert.True(mm.HasGoPointers[hashmap.Hashmap[int, []int]]())
+	assert.True(mm.HasGoPointers[linkedlist.LinkedList[*int]]())
+	assert.True(mm.HasGoPointers[rc.Rc[any]]())
+}
+
+func TestAllowType(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(mm.HasGoPointers[withManualPtr]())
+	mm.AllowType[manual]()
+	assert.False(mm.HasGoPointers[withManualPtr]())
+}
+
+func TestStrictMode(t *testing.T) {
+	assert := assert.New(t)
+	alloc := mmtest.NewAllocator(t)
+
+	// disabled by default
+	assert.False(mm.StrictMode())
+	s := allocator.Alloc[withString](alloc)
+	allocator.Free(alloc, s)
+
+	mm.SetStrictMode(true)
+	defer mm.SetStrictMode(false)
+
+	assert.Panics(func() {
+		allocator.Alloc[withString](alloc)
+	})
+	assert.Panics(func() {
+		allocator.AllocMany[string](alloc, 2)
+	})
+	assert.Panics(func() {
+		vector.New[[]int](alloc)
+	})
+	assert.Panics(func() {
+		hashmap.New[string, int](alloc)
+	})
+	assert.Panics(func() {
+		hashmap.New[int, *int](alloc)
+	})
+	assert.Panics(func() {
+		linkedlist.New[any](alloc)
+	})
+	assert.Panics(func() {
+		hashmap.NewCustom[[]byte, int](alloc, nil, nil)
+	})
+
+	v := vector.New[withVector](alloc)
+	defer v.Free()
+	hm := hashmap.New[int, *vector.Vector[int]](alloc)
+	defer hm.Free()
+	custom := hashmap.NewCustom[hashmap.BytesKey, int](alloc, hashmap.HashBytes, hashmap.EqualBytes)
+	defer custom.Free()
+	str := mmstring.From(alloc, "hello")
+	defer str.Free()
+}
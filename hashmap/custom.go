@@ -0,0 +1,109 @@
+package hashmap
+
+import (
+	"bytes"
+	"hash/maphash"
+	"slices"
+	"unsafe"
+
+	"github.com/joetifa2003/mm-go"
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/mmstring"
+)
+
+// CustomHashmap is a Hashmap that uses user provided hash and equality functions,
+// so keys can be compared by content (MMString, BytesKey) or loosely (case-insensitive).
+// It has all the methods of a Hashmap.
+type CustomHashmap[K any, V any] struct {
+	table[K, V, funcHasher[K]] // must be the first field, so freeing the table frees the whole header
+}
+
+type funcHasher[K any] struct {
+	hash  func(key K) uint64 `mm:"static"`
+	equal func(a, b K) bool  `mm:"static"`
+}
+
+func (h funcHasher[K]) Hash(key K) uint64 {
+	return h.hash(key)
+}
+
+func (h funcHasher[K]) Equal(a, b K) bool {
+	return h.equal(a, b)
+}
+
+// NewCustom creates a new CustomHashmap that hashes keys using hash and compares them using equal,
+// keys that are equal must have the same hash.
+// Keys are stored in manually managed memory like any other Hashmap, so what they point to must be manually managed too,
+// use BytesKey for byte slices and MMString for strings.
+// hash and equal are stored in manually managed memory, see [mm] for the functions that can be stored.
+func NewCustom[K any, V any](alloc allocator.Allocator, hash func(key K) uint64, equal func(a, b K) bool, options ...HashmapOption) *CustomHashmap[K, V] {
+	opts := applyOptions(options)
+	mm.CheckGoPointers[K]()
+	mm.CheckGoPointers[V]()
+
+	hm := allocator.Alloc[CustomHashmap[K, V]](alloc)
+	hm.init(alloc, funcHasher[K]{hash: hash, equal: equal}, opts)
+	return hm
+}
+
+// Clone returns a copy of the CustomHashmap using the same allocator.
+// Keys and values are copied shallowly, pass cloneElem to deep copy them.
+func (hm *CustomHashmap[K, V]) Clone(cloneElem ...func(K, V) (K, V)) *CustomHashmap[K, V] {
+	return hm.CloneInto(hm.alloc, cloneElem...)
+}
+
+// CloneInto returns a copy of the CustomHashmap allocated using alloc.
+// Keys and values are copied shallowly, pass cloneElem to deep copy them.
+func (hm *CustomHashmap[K, V]) CloneInto(alloc allocator.Allocator, cloneElem ...func(K, V) (K, V)) *CustomHashmap[K, V] {
+	clone := NewCustom[K, V](alloc, hm.hasher.hash, hm.hasher.equal, WithLoadFactor(hm.loadFactor))
+	hm.copyInto(&clone.table, cloneElem)
+	return clone
+}
+
+// MoveTo copies the CustomHashmap into alloc and frees the original,
+// the keys and values are moved as is and the original CustomHashmap must not be used afterwards.
+func (hm *CustomHashmap[K, V]) MoveTo(alloc allocator.Allocator) *CustomHashmap[K, V] {
+	moved := hm.CloneInto(alloc)
+	hm.Free()
+	return moved
+}
+
+var seed = maphash.MakeSeed()
+
+// BytesKey is a byte slice key that is compared by content using HashBytes and EqualBytes.
+type BytesKey struct {
+	data []byte `mm:"manual"`
+}
+
+// NewBytesKey wraps data in a BytesKey,
+// data must point into manually managed memory (allocator.AllocMany, Vector.Slice, etc...) and outlive the key if it's stored in a map.
+// Keys that are only used for lookups (Get, Delete) can point anywhere.
+func NewBytesKey(data []byte) BytesKey {
+	return BytesKey{data: data}
+}
+
+// Slice returns the bytes of the key
+func (k BytesKey) Slice() []byte {
+	return k.data
+}
+
+// HashBytes hashes the content of a BytesKey, use it with EqualBytes.
+func HashBytes(key BytesKey) uint64 {
+	return maphash.Bytes(seed, key.data)
+}
+
+// EqualBytes compares the content of two BytesKeys, use it with HashBytes.
+func EqualBytes(a, b BytesKey) bool {
+	return bytes.Equal(a.data, b.data)
+}
+
+// HashMMString hashes the content of an MMString, use it with EqualMMString.
+func HashMMString(key *mmstring.MMString) uint64 {
+	runes := key.Slice()
+	return maphash.Bytes(seed, unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(runes))), len(runes)*mm.SizeOf[rune]()))
+}
+
+// EqualMMString compares the content of two MMStrings, use it with HashMMString.
+func EqualMMString(a, b *mmstring.MMString) bool {
+	return slices.Equal(a.Slice(), b.Slice())
+}
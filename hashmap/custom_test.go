@@ -0,0 +1,104 @@
+package hashmap_test
+
+import (
+	"bytes"
+	"hash/maphash"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/hashmap"
+	"github.com/joetifa2003/mm-go/mmstring"
+	"github.com/joetifa2003/mm-go/mmtest"
+)
+
+var foldSeed = maphash.MakeSeed()
+
+func hashFold(key hashmap.BytesKey) uint64 {
+	return maphash.Bytes(foldSeed, bytes.ToLower(key.Slice()))
+}
+
+func equalFold(a, b hashmap.BytesKey) bool {
+	return bytes.EqualFold(a.Slice(), b.Slice())
+}
+
+// manualKey copies s into manually managed memory, so it can be stored in a map
+func manualKey(t testing.TB, alloc allocator.Allocator, s string) hashmap.BytesKey {
+	data := allocator.AllocMany[byte](alloc, len(s))
+	copy(data, s)
+	t.Cleanup(func() { allocator.FreeMany(alloc, data) })
+	return hashmap.NewBytesKey(data)
+}
+
+func TestCustomHashmapFold(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	hm := hashmap.NewCustom[hashmap.BytesKey, int](alloc, hashFold, equalFold)
+	defer hm.Free()
+
+	hm.Set(manualKey(t, alloc, "Hello"), 1)
+	hm.Set(manualKey(t, alloc, "HELLO"), 2)
+	assert.Equal(1, hm.Len())
+
+	// lookup keys are not stored, so they can be go memory
+	value, ok := hm.Get(hashmap.NewBytesKey([]byte("hello")))
+	assert.True(ok)
+	assert.Equal(2, value)
+
+	hm.Delete(hashmap.NewBytesKey([]byte("hElLo")))
+	assert.Equal(0, hm.Len())
+}
+
+func TestCustomHashmapBytes(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	hm := hashmap.NewCustom[hashmap.BytesKey, int](alloc, hashmap.HashBytes, hashmap.EqualBytes)
+	defer hm.Free()
+
+	keys := allocator.AllocMany[byte](alloc, 100)
+	defer allocator.FreeMany(alloc, keys)
+
+	for i := range 100 {
+		keys[i] = byte(i)
+		hm.Set(hashmap.NewBytesKey(keys[:i]), i) // every prefix is a different key
+	}
+	assert.Equal(100, hm.Len())
+
+	for i := range 100 {
+		value, ok := hm.Get(hashmap.NewBytesKey([]byte(string(keys[:i]))))
+		assert.True(ok)
+		assert.Equal(i, value)
+	}
+
+	clone := hm.Clone()
+	defer clone.Free()
+	clone.Delete(hashmap.NewBytesKey(keys[:10]))
+	assert.Equal(99, clone.Len())
+	assert.Equal(100, hm.Len())
+}
+
+func TestCustomHashmapMMString(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	hm := hashmap.NewCustom[*mmstring.MMString, int](alloc, hashmap.HashMMString, hashmap.EqualMMString)
+	defer hm.Free()
+
+	key := mmstring.From(alloc, "key")
+	defer key.Free()
+	hm.Set(key, 1)
+
+	lookup := mmstring.From(alloc, "key")
+	defer lookup.Free()
+	value, ok := hm.Get(lookup)
+	assert.True(ok)
+	assert.Equal(1, value)
+
+	other := mmstring.From(alloc, "kez")
+	defer other.Free()
+	_, ok = hm.Get(other)
+	assert.False(ok)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/joetifa2003/mm-go/allocator"
 	"github.com/joetifa2003/mm-go/batchallocator"
+	"github.com/joetifa2003/mm-go/mmstring"
 )
 
 func Example() {
@@ -32,3 +33,23 @@ func Example() {
 	// 6
 	// 60
 }
+
+func ExampleNewCustom() {
+	alloc := allocator.NewC()
+	defer alloc.Destroy()
+
+	// keys are compared by content instead of by pointer
+	hm := NewCustom[*mmstring.MMString, int](alloc, HashMMString, EqualMMString)
+	defer hm.Free()
+
+	key := mmstring.From(alloc, "hello")
+	defer key.Free()
+	hm.Set(key, 1)
+
+	lookup := mmstring.From(alloc, "hello")
+	defer lookup.Free()
+	fmt.Println(hm.Get(lookup))
+
+	// Output:
+	// 1 true
+}
@@ -0,0 +1,46 @@
+package hashmap
+
+import "math/bits"
+
+// ctrlBitset has the most significant bit of byte i set if slot i matched
+type ctrlBitset uint64
+
+// first returns the index of the first matched slot
+func (b ctrlBitset) first() int {
+	return bits.TrailingZeros64(uint64(b)) / 8
+}
+
+// removeFirst removes the first matched slot
+func (b ctrlBitset) removeFirst() ctrlBitset {
+	return b & (b - 1)
+}
+
+func (g *group[K, V]) ctrlAt(i int) uint8 {
+	return uint8(g.ctrl >> (8 * i))
+}
+
+func (g *group[K, V]) setCtrl(i int, c uint8) {
+	g.ctrl = g.ctrl&^(0xff<<(8*i)) | uint64(c)<<(8*i)
+}
+
+// matchH2 matches the full slots with control byte h,
+// it can have false positives when a byte is h+1 and the previous byte matches, so keys must be compared
+func (g *group[K, V]) matchH2(h uint8) ctrlBitset {
+	v := g.ctrl ^ (bitsetLSB * uint64(h))
+	return ctrlBitset((v - bitsetLSB) &^ v & bitsetMSB)
+}
+
+// matchEmpty matches the empty slots, empty is the only control byte with the msb set and bit 1 clear
+func (g *group[K, V]) matchEmpty() ctrlBitset {
+	return ctrlBitset(g.ctrl &^ (g.ctrl << 6) & bitsetMSB)
+}
+
+// matchEmptyOrDeleted matches the slots that are not full, they are the only ones with the msb set
+func (g *group[K, V]) matchEmptyOrDeleted() ctrlBitset {
+	return ctrlBitset(g.ctrl & bitsetMSB)
+}
+
+// matchFull matches the full slots
+func (g *group[K, V]) matchFull() ctrlBitset {
+	return ctrlBitset(^g.ctrl & bitsetMSB)
+}
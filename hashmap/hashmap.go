@@ -1,132 +1,269 @@
 package hashmap
 
 import (
+	"fmt"
 	"iter"
+	"unsafe"
 
 	"github.com/dolthub/maphash"
 
 	"github.com/joetifa2003/mm-go"
 	"github.com/joetifa2003/mm-go/allocator"
-	"github.com/joetifa2003/mm-go/linkedlist"
-	"github.com/joetifa2003/mm-go/vector"
+	"github.com/joetifa2003/mm-go/internal/debug"
 )
 
 // Hashmap Manually managed hashmap,
+// it's an open-addressing hash table (SwissTable style) stored in one contiguous manually managed allocation.
+// Slots are split into groups of 8, each group has 8 control bytes describing its slots,
+// so a lookup checks 8 slots at once using the 7 bits of the hash stored in the control bytes.
 type Hashmap[K comparable, V any] struct {
-	pairs      *vector.Vector[*linkedlist.LinkedList[pair[K, V]]]
-	totalTaken int
-	mh         maphash.Hasher[K]
+	table[K, V, defaultHasher[K]] // must be the first field, so freeing the table frees the whole header
+}
+
+// hasher hashes and compares keys, it's a type parameter of table so calls are not dynamic
+type hasher[K any] interface {
+	Hash(key K) uint64
+	Equal(a, b K) bool
+}
+
+type defaultHasher[K comparable] struct {
+	mh maphash.Hasher[K] `mm:"static"` // Holds the runtime hash function of K
+}
+
+func (h defaultHasher[K]) Hash(key K) uint64 {
+	return h.mh.Hash(key)
+}
+
+func (h defaultHasher[K]) Equal(a, b K) bool {
+	return a == b
+}
+
+// table is the implementation shared by Hashmap and CustomHashmap
+type table[K any, V any, H hasher[K]] struct {
+	groups     []group[K, V] `mm:"manual"`
+	used       int           // Number of full slots
+	growthLeft int           // Number of empty slots that can be filled before rehashing
+	minGroups  int           // The table never shrinks below it, set by Reserve
+	deleted    bool          // Set by Delete, the next Set shrinks the table if the deletes made it sparse
+	loadFactor float64
+	hasher     H
 	alloc      allocator.Allocator
 }
 
-type pair[K comparable, V any] struct {
+type hashmapOptions struct {
+	loadFactor float64
+}
+
+type HashmapOption func(opts *hashmapOptions)
+
+// WithLoadFactor Option to specify the maximum ratio of full slots before the Hashmap grows,
+// the default is 0.875, factor must be in the range (0, 1].
+// A lower load factor makes lookups faster and uses more memory.
+func WithLoadFactor(factor float64) HashmapOption {
+	return func(opts *hashmapOptions) {
+		opts.loadFactor = factor
+	}
+}
+
+const (
+	groupSize = 8
+
+	ctrlEmpty   = 0b1000_0000
+	ctrlDeleted = 0b1111_1110
+
+	ctrlEmptyGroup = 0x8080_8080_8080_8080
+	defaultLoad    = 7.0 / 8.0
+	bitsetLSB      = 0x0101_0101_0101_0101
+	bitsetMSB      = 0x8080_8080_8080_8080
+)
+
+type slot[K any, V any] struct {
 	key   K
 	value V
 }
 
-// New creates a new Hashmap with key of type K and value of type V
-func New[K comparable, V any](alloc allocator.Allocator) *Hashmap[K, V] {
+type group[K any, V any] struct {
+	// ctrl holds a control byte for each slot, byte i (bits 8*i to 8*i+7) describes slot i.
+	// A control byte is ctrlEmpty, ctrlDeleted, or the 7 low bits of the hash of a full slot.
+	ctrl  uint64
+	slots [groupSize]slot[K, V]
+}
+
+// New creates a new Hashmap with key of type K and value of type V,
+// and applies optional configuration using HashmapOption
+func New[K comparable, V any](alloc allocator.Allocator, options ...HashmapOption) *Hashmap[K, V] {
+	opts := applyOptions(options)
+	mm.CheckGoPointers[K]()
+	mm.CheckGoPointers[V]()
+
 	hm := allocator.Alloc[Hashmap[K, V]](alloc)
-	hm.pairs = vector.New[*linkedlist.LinkedList[pair[K, V]]](alloc, 8)
-	hm.mh = maphash.NewHasher[K]()
-	hm.alloc = alloc
+	hm.init(alloc, defaultHasher[K]{mh: maphash.NewHasher[K]()}, opts)
 	return hm
 }
 
-func (hm *Hashmap[K, V]) extend() {
-	newPairs := vector.New[*linkedlist.LinkedList[pair[K, V]]](hm.alloc, hm.pairs.Len()*2)
-	oldPairs := hm.pairs
-	defer oldPairs.Free()
+// Clone returns a copy of the Hashmap using the same allocator.
+// Keys and values are copied shallowly, pass cloneElem to deep copy them.
+func (hm *Hashmap[K, V]) Clone(cloneElem ...func(K, V) (K, V)) *Hashmap[K, V] {
+	return hm.CloneInto(hm.alloc, cloneElem...)
+}
 
-	hm.totalTaken = 0
-	hm.pairs = newPairs
+// CloneInto returns a copy of the Hashmap allocated using alloc.
+// Keys and values are copied shallowly, pass cloneElem to deep copy them.
+func (hm *Hashmap[K, V]) CloneInto(alloc allocator.Allocator, cloneElem ...func(K, V) (K, V)) *Hashmap[K, V] {
+	clone := New[K, V](alloc, WithLoadFactor(hm.loadFactor))
+	hm.copyInto(&clone.table, cloneElem)
+	return clone
+}
 
-	for _, pairs := range oldPairs.Iter() {
-		if pairs == nil {
-			continue
-		}
+// MoveTo copies the Hashmap into alloc and frees the original,
+// the keys and values are moved as is and the original Hashmap must not be used afterwards.
+func (hm *Hashmap[K, V]) MoveTo(alloc allocator.Allocator) *Hashmap[K, V] {
+	moved := hm.CloneInto(alloc)
+	hm.Free()
+	return moved
+}
 
-		for _, p := range pairs.Iter() {
-			hm.Set(p.key, p.value)
-		}
+func applyOptions(options []HashmapOption) hashmapOptions {
+	opts := hashmapOptions{loadFactor: defaultLoad}
+	for _, option := range options {
+		option(&opts)
 	}
+
+	if opts.loadFactor <= 0 || opts.loadFactor > 1 {
+		panic(fmt.Sprintf("cannot use a load factor of %v", opts.loadFactor))
+	}
+
+	return opts
+}
+
+func (hm *table[K, V, H]) init(alloc allocator.Allocator, hasher H, opts hashmapOptions) {
+	hm.hasher = hasher
+	hm.alloc = alloc
+	hm.loadFactor = opts.loadFactor
+	hm.minGroups = 1
+	hm.groups = newGroups[K, V](alloc, 1)
+	hm.growthLeft = hm.maxUsed(1)
+	debug.Track(unsafe.Pointer(hm))
+}
+
+func newGroups[K any, V any](alloc allocator.Allocator, n int) []group[K, V] {
+	groups := allocator.AllocMany[group[K, V]](alloc, n)
+	for i := range groups {
+		groups[i].ctrl = ctrlEmptyGroup
+	}
+	return groups
+}
+
+// maxUsed returns the number of slots that can be full in n groups without exceeding the load factor,
+// at least one slot is kept empty so probing always terminates.
+func (hm *table[K, V, H]) maxUsed(n int) int {
+	slots := n * groupSize
+	return max(1, min(slots-1, int(float64(slots)*hm.loadFactor)))
+}
+
+// groupsFor returns the number of groups needed to hold n entries, it's always a power of two
+func (hm *table[K, V, H]) groupsFor(n int) int {
+	groups := 1
+	for hm.maxUsed(groups) < n {
+		groups *= 2
+	}
+	return groups
 }
 
 // Set inserts a new value V if key K doesn't exist,
 // Otherwise update the key K with value V
-func (hm *Hashmap[K, V]) Set(key K, value V) {
-	if ptr, exists := hm.GetPtr(key); exists {
-		*ptr = value
+func (hm *table[K, V, H]) Set(key K, value V) {
+	hm.check()
+
+	hash := hm.hasher.Hash(key)
+	if s, ok := hm.find(key, hash); ok {
+		s.value = value
 		return
 	}
 
-	if hm.totalTaken == hm.pairs.Len() {
-		hm.extend()
+	if hm.deleted {
+		hm.shrink()
+	}
+	g, i := hm.findInsertSlot(hash)
+	if g.ctrlAt(i) == ctrlEmpty && hm.growthLeft == 0 {
+		hm.rehash()
+		g, i = hm.findInsertSlot(hash)
 	}
 
-	hash := hm.mh.Hash(key)
+	if g.ctrlAt(i) == ctrlEmpty {
+		hm.growthLeft--
+	}
+	g.setCtrl(i, h2(hash))
+	g.slots[i] = slot[K, V]{key: key, value: value}
+	hm.used++
+}
 
-	idx := int(hash % uint64(hm.pairs.Len()))
-	pairs := hm.pairs.At(idx)
-	if pairs == nil {
-		newPairs := linkedlist.New[pair[K, V]](hm.alloc)
-		hm.pairs.Set(idx, newPairs)
-		pairs = newPairs
+// Len returns the number of entries in the Hashmap
+func (hm *table[K, V, H]) Len() int {
+	hm.check()
+	return hm.used
+}
+
+// Clear removes all the entries, keeping the capacity
+func (hm *table[K, V, H]) Clear() {
+	hm.check()
+
+	clear(hm.groups)
+	for i := range hm.groups {
+		hm.groups[i].ctrl = ctrlEmptyGroup
 	}
-	pairs.PushBack(pair[K, V]{key: key, value: value})
-	hm.totalTaken++
+	hm.used = 0
+	hm.growthLeft = hm.maxUsed(len(hm.groups))
+	hm.deleted = false
 }
 
-// Get takes key K and return value V
-func (hm *Hashmap[K, V]) Get(key K) (value V, exists bool) {
-	hash := hm.mh.Hash(key)
+// Reserve grows the Hashmap so it can hold n entries without growing,
+// the Hashmap won't shrink below this size after deletes.
+func (hm *table[K, V, H]) Reserve(n int) {
+	hm.check()
 
-	idx := int(hash % uint64(hm.pairs.Len()))
-	pairs := hm.pairs.At(idx)
-	if pairs == nil {
-		return mm.Zero[V](), false
+	hm.minGroups = hm.groupsFor(n)
+	if hm.minGroups > len(hm.groups) {
+		hm.resize(hm.minGroups)
 	}
+}
+
+// Get takes key K and return value V
+func (hm *table[K, V, H]) Get(key K) (value V, exists bool) {
+	hm.check()
 
-	pairIdx, ok := pairs.FindIndex(func(value pair[K, V]) bool {
-		return value.key == key
-	})
+	s, ok := hm.find(key, hm.hasher.Hash(key))
 	if !ok {
 		return mm.Zero[V](), false
 	}
 
-	return pairs.At(pairIdx).value, ok
+	return s.value, true
 }
 
 // GetPtr takes key K and return a pointer to value V
-func (hm *Hashmap[K, V]) GetPtr(key K) (value *V, exists bool) {
-	hash := hm.mh.Hash(key)
-
-	idx := int(hash % uint64(hm.pairs.Len()))
-	pairs := hm.pairs.At(idx)
-	if pairs == nil {
-		return nil, false
-	}
+// CAUTION: the pointer is invalidated when the Hashmap grows
+func (hm *table[K, V, H]) GetPtr(key K) (value *V, exists bool) {
+	hm.check()
 
-	pairIdx, ok := pairs.FindIndex(func(value pair[K, V]) bool {
-		return value.key == key
-	})
+	s, ok := hm.find(key, hm.hasher.Hash(key))
 	if !ok {
 		return nil, false
 	}
 
-	return &pairs.AtPtr(pairIdx).value, ok
+	return &s.value, true
 }
 
-// Iter returns an iterator over all key/value pairs
-func (hm *Hashmap[K, V]) Iter() iter.Seq2[K, V] {
-	return func(yield func(K, V) bool) {
-		for _, pairs := range hm.pairs.Iter() {
-			if pairs == nil {
-				continue
-			}
+// Iter returns an iterator over all key/value pairs,
+// entries can be deleted while iterating, but setting new ones can resize the Hashmap and is not allowed.
+func (hm *table[K, V, H]) Iter() iter.Seq2[K, V] {
+	hm.check()
 
-			for _, pair := range pairs.Iter() {
-				if !yield(pair.key, pair.value) {
+	return func(yield func(K, V) bool) {
+		for gi := range hm.groups {
+			g := &hm.groups[gi]
+			for full := g.matchFull(); full != 0; full = full.removeFirst() {
+				s := &g.slots[full.first()]
+				if !yield(s.key, s.value) {
 					return
 				}
 			}
@@ -135,61 +272,194 @@ func (hm *Hashmap[K, V]) Iter() iter.Seq2[K, V] {
 }
 
 // Values returns all values as a slice
-func (hm *Hashmap[K, V]) Values() []V {
-	res := make([]V, 0)
+func (hm *table[K, V, H]) Values() []V {
+	hm.check()
 
-	for _, pairs := range hm.pairs.Iter() {
-		if pairs == nil {
-			continue
+	res := make([]V, 0, hm.used)
+	for _, value := range hm.Iter() {
+		res = append(res, value)
+	}
+
+	return res
+}
+
+// Keys returns all keys as a slice
+func (hm *table[K, V, H]) Keys() []K {
+	hm.check()
+
+	res := make([]K, 0, hm.used)
+	for key := range hm.Iter() {
+		res = append(res, key)
+	}
+
+	return res
+}
+
+// Delete delete value with key K,
+// it never resizes the Hashmap so it's safe while iterating, the next Set shrinks it if needed.
+func (hm *table[K, V, H]) Delete(key K) {
+	hm.check()
+
+	hash := hm.hasher.Hash(key)
+	for seq := hm.probe(hash); ; seq.next() {
+		g := &hm.groups[seq.offset]
+
+		for match := g.matchH2(h2(hash)); match != 0; match = match.removeFirst() {
+			i := match.first()
+			if !hm.hasher.Equal(g.slots[i].key, key) {
+				continue
+			}
+
+			// lookups stop at groups with an empty slot, so the slot can be emptied if the group has one,
+			// otherwise it's marked as deleted so lookups continue probing after it
+			if g.matchEmpty() != 0 {
+				g.setCtrl(i, ctrlEmpty)
+				hm.growthLeft++
+			} else {
+				g.setCtrl(i, ctrlDeleted)
+			}
+			g.slots[i] = slot[K, V]{}
+			hm.used--
+			hm.deleted = true
+			return
 		}
 
-		for _, p := range pairs.Iter() {
-			res = append(res, p.value)
+		if g.matchEmpty() != 0 {
+			return
 		}
 	}
+}
 
-	return res
+// copyInto sets all the entries in dst, calling cloneElem on them if it's provided
+func (hm *table[K, V, H]) copyInto(dst *table[K, V, H], cloneElem []func(K, V) (K, V)) {
+	hm.check()
+
+	dst.resize(dst.groupsFor(hm.used))
+	for key, value := range hm.Iter() {
+		if len(cloneElem) > 0 {
+			key, value = cloneElem[0](key, value)
+		}
+		dst.Set(key, value)
+	}
 }
 
-// Keys returns all keys as a slice
-func (hm *Hashmap[K, V]) Keys() []K {
-	res := make([]K, 0)
+// Free frees the Hashmap
+func (hm *table[K, V, H]) Free() {
+	debug.Untrack(unsafe.Pointer(hm), "hashmap")
 
-	for _, pairs := range hm.pairs.Iter() {
-		if pairs == nil {
-			continue
+	alloc := hm.alloc
+	groups := hm.groups
+	debug.Poison(unsafe.Pointer(unsafe.SliceData(groups)), len(groups)*mm.SizeOf[group[K, V]]())
+	debug.Poison(unsafe.Pointer(hm), mm.SizeOf[table[K, V, H]]())
+
+	allocator.FreeMany(alloc, groups)
+	allocator.Free(alloc, hm)
+}
+
+// find returns the slot of key K
+func (hm *table[K, V, H]) find(key K, hash uint64) (*slot[K, V], bool) {
+	for seq := hm.probe(hash); ; seq.next() {
+		g := &hm.groups[seq.offset]
+
+		for match := g.matchH2(h2(hash)); match != 0; match = match.removeFirst() {
+			if s := &g.slots[match.first()]; hm.hasher.Equal(s.key, key) {
+				return s, true
+			}
 		}
 
-		for _, p := range pairs.Iter() {
-			res = append(res, p.key)
+		// the key would have been inserted in this group if it had an empty slot
+		if g.matchEmpty() != 0 {
+			return nil, false
 		}
 	}
+}
 
-	return res
+// findInsertSlot returns the first empty or deleted slot in the probe sequence of hash
+func (hm *table[K, V, H]) findInsertSlot(hash uint64) (*group[K, V], int) {
+	for seq := hm.probe(hash); ; seq.next() {
+		g := &hm.groups[seq.offset]
+		if match := g.matchEmptyOrDeleted(); match != 0 {
+			return g, match.first()
+		}
+	}
 }
 
-// Delete delete value with key K
-func (hm *Hashmap[K, V]) Delete(key K) {
-	hash := hm.mh.Hash(key)
+// rehash grows the table if it's more than half full, otherwise it rehashes it in place to drop the deleted slots
+func (hm *table[K, V, H]) rehash() {
+	n := len(hm.groups)
+	if hm.used >= hm.maxUsed(n)/2 {
+		n = max(n*2, hm.groupsFor(hm.used+1))
+	}
+	hm.resize(n)
+}
 
-	idx := int(hash % uint64(hm.pairs.Len()))
-	pairs := hm.pairs.At(idx)
-	if pairs == nil {
-		return
+// shrink halves the table while it's less than a quarter full,
+// the gap between growing and shrinking prevents resizing back and forth.
+// It's called by the Set after a Delete instead of Delete itself, so deleting while iterating doesn't free the groups,
+// and tables emptied with Clear or sized by copyInto are not shrunk.
+func (hm *table[K, V, H]) shrink() {
+	hm.deleted = false
+
+	n := len(hm.groups)
+	for n > hm.minGroups && hm.used < hm.maxUsed(n)/4 && hm.used < hm.maxUsed(n/2) {
+		n /= 2
 	}
 
-	pairs.Remove(func(idx int, p pair[K, V]) bool {
-		return p.key == key
-	})
+	if n != len(hm.groups) {
+		hm.resize(n)
+	}
 }
 
-// Free frees the Hashmap
-func (hm *Hashmap[K, V]) Free() {
-	for _, pairs := range hm.pairs.Iter() {
-		if pairs != nil {
-			pairs.Free()
+// resize moves all the full slots into n new groups
+func (hm *table[K, V, H]) resize(n int) {
+	oldGroups := hm.groups
+	hm.groups = newGroups[K, V](hm.alloc, n)
+	hm.growthLeft = hm.maxUsed(n) - hm.used
+	hm.deleted = false
+
+	for gi := range oldGroups {
+		old := &oldGroups[gi]
+		for full := old.matchFull(); full != 0; full = full.removeFirst() {
+			s := &old.slots[full.first()]
+			hash := hm.hasher.Hash(s.key)
+			g, i := hm.findInsertSlot(hash)
+			g.setCtrl(i, h2(hash))
+			g.slots[i] = *s
 		}
 	}
-	hm.pairs.Free()
-	allocator.Free(hm.alloc, hm)
+
+	allocator.FreeMany(hm.alloc, oldGroups)
+}
+
+// check panics if the hashmap is freed, only in debug builds (mmdebug build tag)
+func (hm *table[K, V, H]) check() {
+	debug.CheckLive(unsafe.Pointer(hm), "hashmap")
+}
+
+// h1 selects the first group to probe
+func h1(hash uint64) uint64 {
+	return hash >> 7
+}
+
+// h2 is stored in the control byte of a full slot
+func h2(hash uint64) uint8 {
+	return uint8(hash & 0x7f)
+}
+
+// probeSeq is a quadratic probe sequence over the groups, it visits every group once
+// because the number of groups is a power of two.
+type probeSeq struct {
+	mask   uint64
+	offset uint64
+	index  uint64
+}
+
+func (hm *table[K, V, H]) probe(hash uint64) probeSeq {
+	mask := uint64(len(hm.groups) - 1)
+	return probeSeq{mask: mask, offset: h1(hash) & mask}
+}
+
+func (s *probeSeq) next() {
+	s.index++
+	s.offset = (s.offset + s.index) & s.mask
 }
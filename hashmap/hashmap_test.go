@@ -1,16 +1,142 @@
 package hashmap_test
 
 import (
+	"math/rand/v2"
 	"runtime"
 	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
 
 	"github.com/joetifa2003/mm-go/allocator"
 	"github.com/joetifa2003/mm-go/batchallocator"
 	"github.com/joetifa2003/mm-go/hashmap"
+	"github.com/joetifa2003/mm-go/mmtest"
 )
 
 const TIMES = 500
 
+func TestHashmap(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	hm := hashmap.New[int, int](alloc)
+	defer hm.Free()
+
+	hm.Set(1, 10)
+	hm.Set(2, 20)
+	hm.Set(1, 11)
+
+	value, ok := hm.Get(1)
+	assert.True(ok)
+	assert.Equal(11, value)
+
+	ptr, ok := hm.GetPtr(2)
+	assert.True(ok)
+	*ptr = 21
+	value, _ = hm.Get(2)
+	assert.Equal(21, value)
+
+	_, ok = hm.Get(3)
+	assert.False(ok)
+	_, ok = hm.GetPtr(3)
+	assert.False(ok)
+
+	hm.Delete(1)
+	hm.Delete(3)
+	_, ok = hm.Get(1)
+	assert.False(ok)
+	assert.Equal([]int{2}, hm.Keys())
+	assert.Equal([]int{21}, hm.Values())
+}
+
+func TestHashmapRandom(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	hm := hashmap.New[int, int](alloc)
+	defer hm.Free()
+
+	expected := map[int]int{}
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	// a small key space makes deletes and reinserts hit the same slots
+	for i := range 20000 {
+		key := rng.IntN(2000)
+		switch rng.IntN(3) {
+		case 0, 1:
+			hm.Set(key, i)
+			expected[key] = i
+		case 2:
+			hm.Delete(key)
+			delete(expected, key)
+		}
+	}
+
+	for key := range 2000 {
+		value, ok := hm.Get(key)
+		expectedValue, expectedOk := expected[key]
+		assert.Equal(expectedOk, ok, "key %d", key)
+		assert.Equal(expectedValue, value, "key %d", key)
+	}
+
+	actual := map[int]int{}
+	for key, value := range hm.Iter() {
+		actual[key] = value
+	}
+	assert.Equal(expected, actual)
+}
+
+func TestHashmapChurn(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	hm := hashmap.New[int, int](alloc)
+	defer hm.Free()
+
+	// insert and delete new keys forever, deleted slots must be reclaimed
+	for i := range 100000 {
+		hm.Set(i, i)
+		if i >= 10 {
+			hm.Delete(i - 10)
+		}
+	}
+
+	assert.Len(hm.Keys(), 10)
+	for i := 100000 - 10; i < 100000; i++ {
+		value, ok := hm.Get(i)
+		assert.True(ok)
+		assert.Equal(i, value)
+	}
+}
+
+func TestHashmapClone(t *testing.T) {
+	scratch := mmtest.NewAllocator(t)
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	hm := hashmap.New[int, int](scratch)
+	for i := range 20 {
+		hm.Set(i, i)
+	}
+
+	clone := hm.Clone(func(k, v int) (int, int) { return k, v * 2 })
+	defer clone.Free()
+
+	moved := hm.MoveTo(alloc)
+	defer moved.Free()
+
+	for i := range 20 {
+		value, ok := moved.Get(i)
+		assert.True(ok)
+		assert.Equal(i, value)
+
+		value, ok = clone.Get(i)
+		assert.True(ok)
+		assert.Equal(i*2, value)
+	}
+}
+
 func BenchmarkHashmapGo(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		h := newMap()
@@ -51,6 +177,247 @@ func BenchmarkHashmapBatchAlloc(b *testing.B) {
 	}
 }
 
+func BenchmarkHashmapGetGo(b *testing.B) {
+	h := newMap()
+	for i := 0; i < TIMES; i++ {
+		h[i] = i
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < TIMES; j++ {
+			_ = h[j]
+		}
+	}
+}
+
+func BenchmarkHashmapGet(b *testing.B) {
+	alloc := allocator.NewC()
+	defer alloc.Destroy()
+
+	h := hashmap.New[int, int](alloc)
+	defer h.Free()
+	for i := 0; i < TIMES; i++ {
+		h.Set(i, i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < TIMES; j++ {
+			h.Get(j)
+		}
+	}
+}
+
 func newMap() map[int]int {
 	return make(map[int]int)
 }
+
+func TestHashmapLen(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	hm := hashmap.New[int, int](alloc)
+	defer hm.Free()
+
+	for i := range 100 {
+		hm.Set(i, i)
+	}
+	hm.Set(0, 1)
+	assert.Equal(100, hm.Len())
+
+	for i := range 50 {
+		hm.Delete(i)
+	}
+	hm.Delete(0)
+	assert.Equal(50, hm.Len())
+
+	hm.Clear()
+	assert.Equal(0, hm.Len())
+	assert.Empty(hm.Keys())
+	_, ok := hm.Get(60)
+	assert.False(ok)
+
+	hm.Set(1, 1)
+	assert.Equal(1, hm.Len())
+}
+
+// withBatchAllocator calls f with a new batch allocator, its stats are used to measure the hashmap memory
+func withBatchAllocator(f func(alloc allocator.Allocator)) {
+	alloc := batchallocator.New(allocator.NewC())
+	defer alloc.Destroy()
+
+	f(alloc)
+}
+
+func liveBytes(alloc allocator.Allocator) int {
+	stats, _ := batchallocator.GetStats(alloc)
+	return stats.LiveBytes
+}
+
+func TestHashmapShrink(t *testing.T) {
+	assert := assert.New(t)
+
+	var full, afterDelete, reserved int
+	withBatchAllocator(func(alloc allocator.Allocator) {
+		hm := hashmap.New[int, int](alloc)
+		for i := range 10000 {
+			hm.Set(i, i)
+		}
+		full = liveBytes(alloc)
+
+		for i := range 9990 {
+			hm.Delete(i)
+		}
+		assert.Equal(full, liveBytes(alloc)) // deleting doesn't shrink, the next Set does
+
+		hm.Set(-1, -1)
+		afterDelete = liveBytes(alloc)
+
+		for i := 9990; i < 10000; i++ {
+			value, ok := hm.Get(i)
+			assert.True(ok)
+			assert.Equal(i, value)
+		}
+	})
+	assert.Less(afterDelete*100, full)
+
+	withBatchAllocator(func(alloc allocator.Allocator) {
+		hm := hashmap.New[int, int](alloc)
+		hm.Reserve(10000)
+		reserved = liveBytes(alloc)
+
+		// reserving doesn't grow again, and deleting doesn't shrink below the reserved size
+		for i := range 10000 {
+			hm.Set(i, i)
+		}
+		assert.Equal(reserved, liveBytes(alloc))
+		hm.Clear()
+		hm.Set(1, 1)
+		hm.Delete(1)
+		assert.Equal(reserved, liveBytes(alloc))
+	})
+}
+
+func TestHashmapDeleteWhileIterating(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	hm := hashmap.New[int, int](alloc)
+	defer hm.Free()
+
+	for i := range 1000 {
+		hm.Set(i, i)
+	}
+
+	seen := 0
+	for key, value := range hm.Iter() {
+		assert.Equal(key, value)
+		hm.Delete(key)
+		seen++
+	}
+	assert.Equal(1000, seen)
+	assert.Equal(0, hm.Len())
+
+	hm.Set(1, 1)
+	assert.Equal([]int{1}, hm.Keys())
+}
+
+func TestHashmapShrinkOnlyAfterDelete(t *testing.T) {
+	assert := assert.New(t)
+
+	withBatchAllocator(func(alloc allocator.Allocator) {
+		hm := hashmap.New[int, int](alloc)
+		for i := range 10000 {
+			hm.Set(i, i)
+		}
+		full := liveBytes(alloc)
+
+		// Clear keeps the capacity without Reserve
+		hm.Clear()
+		hm.Set(1, 1)
+		assert.Equal(full, liveBytes(alloc))
+	})
+
+	// the clone is sized for the entries once, it doesn't shrink on the first Set and grow again
+	alloc := mmtest.NewAllocator(t)
+	hm := hashmap.New[int, int](alloc)
+	defer hm.Free()
+	for i := range 10000 {
+		hm.Set(i, i)
+	}
+
+	cloneAlloc, counts := newCountingAllocator(t)
+	clone := hm.CloneInto(cloneAlloc)
+	defer clone.Free()
+	assert.Equal(3, counts.allocs) // the header, the initial group and the groups sized for the entries
+}
+
+// counts is the state of a counting allocator, it's allocated in C memory
+// because the allocator is copied into the hashmaps it allocates
+type counts struct {
+	c      allocator.Allocator
+	allocs int
+}
+
+// newCountingAllocator returns a C allocator that counts its allocations
+func newCountingAllocator(t testing.TB) (allocator.Allocator, *counts) {
+	c := allocator.NewC()
+	state := allocator.Alloc[counts](c)
+	state.c = c
+	t.Cleanup(func() {
+		allocator.Free(c, state)
+		c.Destroy()
+	})
+
+	return allocator.NewAllocator(unsafe.Pointer(state), countingAlloc, countingFree, countingRealloc, countingDestroy), state
+}
+
+func countingAlloc(a unsafe.Pointer, size int) unsafe.Pointer {
+	state := (*counts)(a)
+	state.allocs++
+	return state.c.Alloc(size)
+}
+
+func countingFree(a unsafe.Pointer, ptr unsafe.Pointer) {
+	(*counts)(a).c.Free(ptr)
+}
+
+func countingRealloc(a unsafe.Pointer, ptr unsafe.Pointer, size int) unsafe.Pointer {
+	state := (*counts)(a)
+	state.allocs++
+	return state.c.Realloc(ptr, size)
+}
+
+func countingDestroy(a unsafe.Pointer) {}
+
+func TestHashmapLoadFactor(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	sparse := hashmap.New[int, int](alloc, hashmap.WithLoadFactor(0.01))
+	defer sparse.Free()
+	full := hashmap.New[int, int](alloc, hashmap.WithLoadFactor(1))
+	defer full.Free()
+
+	for i := range 1000 {
+		sparse.Set(i, i)
+		full.Set(i, i)
+	}
+	for i := range 1000 {
+		value, ok := sparse.Get(i)
+		assert.True(ok)
+		assert.Equal(i, value)
+
+		value, ok = full.Get(i)
+		assert.True(ok)
+		assert.Equal(i, value)
+	}
+
+	assert.Panics(func() {
+		hashmap.New[int, int](alloc, hashmap.WithLoadFactor(0))
+	})
+	assert.Panics(func() {
+		hashmap.New[int, int](alloc, hashmap.WithLoadFactor(1.5))
+	})
+}
@@ -0,0 +1,116 @@
+// debug contains checks that are only enabled when building with the mmdebug build tag.
+// When the tag is not set Enabled is false and all checks are removed by the compiler.
+package debug
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+var (
+	liveMu sync.Mutex
+	live   = map[unsafe.Pointer]struct{}{}
+)
+
+// Track marks ptr as live, it does nothing if Enabled is false.
+func Track(ptr unsafe.Pointer) {
+	if !Enabled {
+		return
+	}
+
+	liveMu.Lock()
+	live[ptr] = struct{}{}
+	liveMu.Unlock()
+}
+
+// Untrack marks ptr as freed and panics if it's not live, it does nothing if Enabled is false.
+func Untrack(ptr unsafe.Pointer, what string) {
+	if !Enabled {
+		return
+	}
+
+	liveMu.Lock()
+	defer liveMu.Unlock()
+
+	if _, ok := live[ptr]; !ok {
+		panic(fmt.Sprintf("double free of %s", what))
+	}
+	delete(live, ptr)
+}
+
+// CheckLive panics if ptr is not live, it does nothing if Enabled is false.
+func CheckLive(ptr unsafe.Pointer, what string) {
+	if !Enabled {
+		return
+	}
+
+	liveMu.Lock()
+	defer liveMu.Unlock()
+
+	if _, ok := live[ptr]; !ok {
+		panic(fmt.Sprintf("use after free of %s", what))
+	}
+}
+
+// PoisonByte is the pattern written to freed memory.
+const PoisonByte = 0xDE
+
+// Poison fills size bytes at ptr with PoisonByte, it does nothing if Enabled is false.
+func Poison(ptr unsafe.Pointer, size int) {
+	if !Enabled || ptr == nil || size <= 0 {
+		return
+	}
+
+	data := unsafe.Slice((*byte)(ptr), size)
+	for i := range data {
+		data[i] = PoisonByte
+	}
+}
+
+var (
+	destroyedMu sync.Mutex
+	destroyed   = map[unsafe.Pointer]struct{}{}
+)
+
+// AllocatorCreated marks the allocator state ptr as usable, it does nothing if Enabled is false.
+// Allocators with a nil state can't be tracked.
+func AllocatorCreated(ptr unsafe.Pointer) {
+	if !Enabled || ptr == nil {
+		return
+	}
+
+	destroyedMu.Lock()
+	delete(destroyed, ptr)
+	destroyedMu.Unlock()
+}
+
+// AllocatorDestroyed marks the allocator state ptr as destroyed and panics if it's already destroyed,
+// it does nothing if Enabled is false.
+func AllocatorDestroyed(ptr unsafe.Pointer) {
+	if !Enabled || ptr == nil {
+		return
+	}
+
+	destroyedMu.Lock()
+	defer destroyedMu.Unlock()
+
+	if _, ok := destroyed[ptr]; ok {
+		panic("allocator destroyed twice")
+	}
+	destroyed[ptr] = struct{}{}
+}
+
+// CheckAllocator panics if the allocator state ptr is destroyed, it does nothing if Enabled is false.
+func CheckAllocator(ptr unsafe.Pointer) {
+	if !Enabled || ptr == nil {
+		return
+	}
+
+	destroyedMu.Lock()
+	defer destroyedMu.Unlock()
+
+	if _, ok := destroyed[ptr]; ok {
+		panic("allocator used after Destroy")
+	}
+}
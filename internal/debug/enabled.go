@@ -0,0 +1,6 @@
+//go:build mmdebug
+
+package debug
+
+// Enabled reports whether the mmdebug build tag is set.
+const Enabled = true
@@ -3,8 +3,11 @@ package linkedlist
 import (
 	"fmt"
 	"iter"
+	"unsafe"
 
+	"github.com/joetifa2003/mm-go"
 	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/internal/debug"
 )
 
 var popEmptyMsg = "cannot pop empty linked list"
@@ -27,8 +30,11 @@ type LinkedList[T any] struct {
 
 // New creates a new linked list.
 func New[T any](alloc allocator.Allocator) *LinkedList[T] {
+	mm.CheckGoPointers[T]()
+
 	linkedList := allocator.Alloc[LinkedList[T]](alloc)
 	linkedList.alloc = alloc
+	debug.Track(unsafe.Pointer(linkedList))
 
 	return linkedList
 }
@@ -51,6 +57,8 @@ func (ll *LinkedList[T]) popLast() T {
 
 // PushBack pushes value T to the back of the linked list.
 func (ll *LinkedList[T]) PushBack(value T) {
+	ll.check()
+
 	// initialize the linked list
 	if ll.head == nil && ll.tail == nil {
 		ll.init(value)
@@ -67,6 +75,8 @@ func (ll *LinkedList[T]) PushBack(value T) {
 
 // PushFront pushes value T to the back of the linked list.
 func (ll *LinkedList[T]) PushFront(value T) {
+	ll.check()
+
 	// initialize the linked list
 	if ll.head == nil && ll.tail == nil {
 		ll.init(value)
@@ -83,6 +93,8 @@ func (ll *LinkedList[T]) PushFront(value T) {
 
 // PopBack pops and returns value T from the back of the linked list.
 func (ll *LinkedList[T]) PopBack() T {
+	ll.check()
+
 	if ll.length == 0 {
 		panic(popEmptyMsg)
 	}
@@ -103,6 +115,8 @@ func (ll *LinkedList[T]) PopBack() T {
 
 // PopFront pops and returns value T from the front of the linked list.
 func (ll *LinkedList[T]) PopFront() T {
+	ll.check()
+
 	if ll.length == 0 {
 		panic(popEmptyMsg)
 	}
@@ -123,6 +137,8 @@ func (ll *LinkedList[T]) PopFront() T {
 
 // ForEach iterates through the linked list.
 func (ll *LinkedList[T]) ForEach(f func(idx int, value T)) {
+	ll.check()
+
 	idx := 0
 
 	currentNode := ll.head
@@ -135,6 +151,8 @@ func (ll *LinkedList[T]) ForEach(f func(idx int, value T)) {
 
 // Iter returns an iterator over the linked list values.
 func (ll *LinkedList[T]) Iter() iter.Seq2[int, T] {
+	ll.check()
+
 	return func(yield func(int, T) bool) {
 		idx := 0
 		currentNode := ll.head
@@ -149,7 +167,8 @@ func (ll *LinkedList[T]) Iter() iter.Seq2[int, T] {
 }
 
 func (ll *LinkedList[T]) nodeAt(idx int) *linkedListNode[T] {
-	if idx >= ll.length {
+	// negative indexes are only checked in debug builds (mmdebug build tag)
+	if idx >= ll.length || (debug.Enabled && idx < 0) {
 		panic(fmt.Sprintf("cannot index %d in a linked list with length %d", idx, ll.length))
 	}
 
@@ -165,16 +184,22 @@ func (ll *LinkedList[T]) nodeAt(idx int) *linkedListNode[T] {
 
 // At gets value T at idx.
 func (ll *LinkedList[T]) At(idx int) T {
+	ll.check()
+
 	return ll.nodeAt(idx).value
 }
 
 // AtPtr gets a pointer to value T at idx.
 func (ll *LinkedList[T]) AtPtr(idx int) *T {
+	ll.check()
+
 	return &ll.nodeAt(idx).value
 }
 
 // RemoveAt removes value T at specified index and returns it.
 func (ll *LinkedList[T]) RemoveAt(idx int) T {
+	ll.check()
+
 	node := ll.nodeAt(idx)
 	if node.prev == nil {
 		return ll.PopFront()
@@ -199,6 +224,8 @@ func (ll *LinkedList[T]) RemoveAt(idx int) T {
 // Remove removes the first value T that pass the test implemented by the provided function.
 // if the test succeeded it will return the value and true
 func (ll *LinkedList[T]) Remove(f func(idx int, value T) bool) (value T, ok bool) {
+	ll.check()
+
 	i := 0
 	currentNode := ll.head
 	for currentNode != nil {
@@ -218,6 +245,8 @@ func (ll *LinkedList[T]) Remove(f func(idx int, value T) bool) (value T, ok bool
 
 // RemoveAll removes all values of T that pass the test implemented by the provided function.
 func (ll *LinkedList[T]) RemoveAll(f func(idx int, value T) bool) []T {
+	ll.check()
+
 	res := []T{}
 
 	i := 0
@@ -239,6 +268,8 @@ func (ll *LinkedList[T]) RemoveAll(f func(idx int, value T) bool) []T {
 
 // FindIndex returns the first index of value T that pass the test implemented by the provided function.
 func (ll *LinkedList[T]) FindIndex(f func(value T) bool) (idx int, ok bool) {
+	ll.check()
+
 	i := 0
 	currentNode := ll.head
 	for currentNode != nil {
@@ -257,6 +288,8 @@ func (ll *LinkedList[T]) FindIndex(f func(value T) bool) (idx int, ok bool) {
 
 // FindIndex returns all indexes of value T that pass the test implemented by the provided function.
 func (ll *LinkedList[T]) FindIndexes(f func(value T) bool) []int {
+	ll.check()
+
 	res := []int{}
 
 	i := 0
@@ -277,18 +310,61 @@ func (ll *LinkedList[T]) FindIndexes(f func(value T) bool) []int {
 
 // Len gets linked list length.
 func (ll *LinkedList[T]) Len() int {
+	ll.check()
+
 	return ll.length
 }
 
+// Clone returns a copy of the linked list using the same allocator.
+// Values are copied shallowly, pass cloneElem to deep copy them.
+func (ll *LinkedList[T]) Clone(cloneElem ...func(T) T) *LinkedList[T] {
+	return ll.CloneInto(ll.alloc, cloneElem...)
+}
+
+// CloneInto returns a copy of the linked list allocated using alloc.
+// Values are copied shallowly, pass cloneElem to deep copy them.
+func (ll *LinkedList[T]) CloneInto(alloc allocator.Allocator, cloneElem ...func(T) T) *LinkedList[T] {
+	ll.check()
+
+	clone := New[T](alloc)
+	for currentNode := ll.head; currentNode != nil; currentNode = currentNode.next {
+		value := currentNode.value
+		if len(cloneElem) > 0 {
+			value = cloneElem[0](value)
+		}
+		clone.PushBack(value)
+	}
+
+	return clone
+}
+
+// MoveTo copies the linked list into alloc and frees the original,
+// the values are moved as is and the original linked list must not be used afterwards.
+func (ll *LinkedList[T]) MoveTo(alloc allocator.Allocator) *LinkedList[T] {
+	moved := ll.CloneInto(alloc)
+	ll.Free()
+	return moved
+}
+
 // Free frees the linked list.
 func (ll *LinkedList[T]) Free() {
+	debug.Untrack(unsafe.Pointer(ll), "linked list")
+
+	alloc := ll.alloc
 	currentNode := ll.head
 
 	for currentNode != nil {
 		nextNode := currentNode.next
-		allocator.Free(ll.alloc, currentNode)
+		debug.Poison(unsafe.Pointer(currentNode), mm.SizeOf[linkedListNode[T]]())
+		allocator.Free(alloc, currentNode)
 		currentNode = nextNode
 	}
 
-	allocator.Free(ll.alloc, ll)
+	debug.Poison(unsafe.Pointer(ll), mm.SizeOf[LinkedList[T]]())
+	allocator.Free(alloc, ll)
+}
+
+// check panics if the linked list is freed, only in debug builds (mmdebug build tag)
+func (ll *LinkedList[T]) check() {
+	debug.CheckLive(unsafe.Pointer(ll), "linked list")
 }
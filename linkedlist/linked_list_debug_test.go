@@ -0,0 +1,27 @@
+//go:build mmdebug
+
+package linkedlist_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/linkedlist"
+)
+
+func TestLinkedListDebug(t *testing.T) {
+	alloc := allocator.NewC()
+	defer alloc.Destroy()
+	assert := assert.New(t)
+
+	ll := linkedlist.New[int](alloc)
+	ll.PushBack(1)
+
+	assert.PanicsWithValue("cannot index -1 in a linked list with length 1", func() { ll.At(-1) })
+
+	ll.Free()
+	assert.PanicsWithValue("use after free of linked list", func() { ll.PushBack(2) })
+	assert.PanicsWithValue("double free of linked list", func() { ll.Free() })
+}
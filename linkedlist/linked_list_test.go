@@ -5,12 +5,12 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
-	"github.com/joetifa2003/mm-go/allocator"
 	"github.com/joetifa2003/mm-go/linkedlist"
+	"github.com/joetifa2003/mm-go/mmtest"
 )
 
 func testPushAndPop(t *testing.T) {
-	alloc := allocator.NewC()
+	alloc := mmtest.NewAllocator(t)
 
 	assert := assert.New(t)
 
@@ -52,7 +52,7 @@ func testPushAndPop(t *testing.T) {
 func testForEach(t *testing.T) {
 	assert := assert.New(t)
 
-	alloc := allocator.NewC()
+	alloc := mmtest.NewAllocator(t)
 
 	ll := linkedlist.New[int](alloc)
 	defer ll.Free()
@@ -76,7 +76,7 @@ func testForEach(t *testing.T) {
 }
 
 func testIndexing(t *testing.T) {
-	alloc := allocator.NewC()
+	alloc := mmtest.NewAllocator(t)
 	assert := assert.New(t)
 
 	ll := linkedlist.New[int](alloc)
@@ -118,7 +118,7 @@ func testIndexing(t *testing.T) {
 func testRemove(t *testing.T) {
 	assert := assert.New(t)
 
-	alloc := allocator.NewC()
+	alloc := mmtest.NewAllocator(t)
 
 	ll := linkedlist.New[int](alloc)
 	defer ll.Free()
@@ -173,9 +173,40 @@ func testRemove(t *testing.T) {
 	assert.Equal(15, val)
 }
 
+func testClone(t *testing.T) {
+	scratch := mmtest.NewAllocator(t)
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	ll := linkedlist.New[int](scratch)
+	ll.PushBack(1)
+	ll.PushBack(2)
+
+	clone := ll.Clone(func(i int) int { return i * 10 })
+	defer clone.Free()
+
+	moved := ll.MoveTo(alloc)
+	defer moved.Free()
+
+	assert.Equal([]int{1, 2}, linkedListToSlice(moved))
+	assert.Equal([]int{10, 20}, linkedListToSlice(clone))
+
+	moved.PushBack(3)
+	assert.Equal(2, clone.Len())
+}
+
+func linkedListToSlice[T any](ll *linkedlist.LinkedList[T]) []T {
+	res := []T{}
+	for _, value := range ll.Iter() {
+		res = append(res, value)
+	}
+	return res
+}
+
 func TestLinkedList(t *testing.T) {
 	t.Run("push and pop", testPushAndPop)
 	t.Run("for each", testForEach)
 	t.Run("indexing", testIndexing)
 	t.Run("remove", testRemove)
+	t.Run("clone", testClone)
 }
@@ -33,7 +33,7 @@ func Example() {
 
 func int_greater(a, b int) bool { return a > b }
 
-func Example_MaxHeap() {
+func Example_maxHeap() {
 	alloc := allocator.NewC()
 	defer alloc.Destroy()
 
@@ -2,34 +2,43 @@ package minheap
 
 import (
 	"iter"
+	"unsafe"
 
+	"github.com/joetifa2003/mm-go"
 	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/internal/debug"
 	"github.com/joetifa2003/mm-go/vector"
 )
 
 type MinHeap[T any] struct {
 	alloc allocator.Allocator
 	data  *vector.Vector[T]
-	less  func(a, b T) bool // check if a < b
+	less  func(a, b T) bool `mm:"static"` // check if a < b
 }
 
 // New creates a new MinHeap.
+// less is stored in manually managed memory, see [mm] for the functions that can be stored.
 func New[T any](alloc allocator.Allocator, less func(a, b T) bool) *MinHeap[T] {
 	minHeap := allocator.Alloc[MinHeap[T]](alloc)
 	minHeap.alloc = alloc
 	minHeap.data = vector.New[T](alloc) // Start with an initial capacity of 16
 	minHeap.less = less
+	debug.Track(unsafe.Pointer(minHeap))
 	return minHeap
 }
 
 // Push adds a value to the heap.
 func (h *MinHeap[T]) Push(value T) {
+	h.check()
+
 	h.data.Push(value)
 	h.heapifyUp(h.data.Len() - 1)
 }
 
 // Pop removes and returns the minimum value from the heap.
 func (h *MinHeap[T]) Pop() T {
+	h.check()
+
 	if h.data.Len() == 0 {
 		panic("cannot pop from empty heap")
 	}
@@ -42,6 +51,8 @@ func (h *MinHeap[T]) Pop() T {
 
 // Peek returns the minimum value from the heap without removing it.
 func (h *MinHeap[T]) Peek() T {
+	h.check()
+
 	if h.data.Len() == 0 {
 		panic("cannot peek into empty heap")
 	}
@@ -50,17 +61,57 @@ func (h *MinHeap[T]) Peek() T {
 
 // Len returns the number of elements in the heap.
 func (h *MinHeap[T]) Len() int {
+	h.check()
+
 	return h.data.Len()
 }
 
+// Clone returns a copy of the heap using the same allocator.
+// Values are copied shallowly, pass cloneElem to deep copy them.
+func (h *MinHeap[T]) Clone(cloneElem ...func(T) T) *MinHeap[T] {
+	return h.CloneInto(h.alloc, cloneElem...)
+}
+
+// CloneInto returns a copy of the heap allocated using alloc.
+// Values are copied shallowly, pass cloneElem to deep copy them.
+func (h *MinHeap[T]) CloneInto(alloc allocator.Allocator, cloneElem ...func(T) T) *MinHeap[T] {
+	h.check()
+
+	clone := allocator.Alloc[MinHeap[T]](alloc)
+	clone.alloc = alloc
+	clone.data = h.data.CloneInto(alloc, cloneElem...)
+	clone.less = h.less
+	debug.Track(unsafe.Pointer(clone))
+	return clone
+}
+
+// MoveTo copies the heap into alloc and frees the original,
+// the values are moved as is and the original heap must not be used afterwards.
+func (h *MinHeap[T]) MoveTo(alloc allocator.Allocator) *MinHeap[T] {
+	moved := h.CloneInto(alloc)
+	h.Free()
+	return moved
+}
+
 // Free frees the heap.
 func (h *MinHeap[T]) Free() {
+	debug.Untrack(unsafe.Pointer(h), "min heap")
+
+	alloc := h.alloc
 	h.data.Free()
-	allocator.Free(h.alloc, h)
+	debug.Poison(unsafe.Pointer(h), mm.SizeOf[MinHeap[T]]())
+	allocator.Free(alloc, h)
+}
+
+// check panics if the heap is freed, only in debug builds (mmdebug build tag)
+func (h *MinHeap[T]) check() {
+	debug.CheckLive(unsafe.Pointer(h), "min heap")
 }
 
 // Remove the first element that makes f return true
 func (h *MinHeap[T]) Remove(f func(T) bool) {
+	h.check()
+
 	for i := 0; i < h.data.Len(); i++ {
 		if f(h.data.UnsafeAt(i)) {
 			h.removeAt(i)
@@ -123,5 +174,7 @@ func (h *MinHeap[T]) removeAt(index int) {
 
 // Iter returns an iterator over the elements of the heap.
 func (h *MinHeap[T]) Iter() iter.Seq2[int, T] {
+	h.check()
+
 	return h.data.Iter()
 }
@@ -3,15 +3,41 @@ package minheap
 import (
 	"testing"
 
-	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/mmtest"
 )
 
 func TestMinHeap(t *testing.T) {
-	alloc := allocator.NewC()
+	alloc := mmtest.NewAllocator(t)
 	heap := New[int](alloc, func(a, b int) bool { return a < b })
+	defer heap.Free()
 
 	heap.Push(3)
 	heap.Push(4)
 	heap.Push(1)
 	heap.Push(0)
 }
+
+func TestMinHeapClone(t *testing.T) {
+	scratch := mmtest.NewAllocator(t)
+	alloc := mmtest.NewAllocator(t)
+
+	heap := New[int](scratch, func(a, b int) bool { return a < b })
+	heap.Push(3)
+	heap.Push(1)
+	heap.Push(2)
+
+	clone := heap.Clone()
+	defer clone.Free()
+
+	moved := heap.MoveTo(alloc)
+	defer moved.Free()
+
+	for _, expected := range []int{1, 2, 3} {
+		if got := moved.Pop(); got != expected {
+			t.Fatalf("expected %d, got %d", expected, got)
+		}
+	}
+	if clone.Len() != 3 {
+		t.Fatalf("expected clone length 3, got %d", clone.Len())
+	}
+}
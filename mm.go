@@ -1,3 +1,14 @@
+// mm provides the helpers shared by all mm-go packages.
+//
+// # Functions in manually managed memory
+//
+// Some constructors store functions next to the data in manually managed memory,
+// like the destructors of rc.NewWithDestructor and typedarena.NewWithDestructor, the less function of minheap.New
+// and the hash functions of hashmap.NewCustom.
+// The GC doesn't scan that memory, so a stored function must be a non generic top level function
+// or a function literal that captures nothing, those are static and never collected.
+// Closures that capture variables and generic functions are allocated on the go heap,
+// they can only be used if the caller keeps them alive until the container is freed.
 package mm
 
 import "unsafe"
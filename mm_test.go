@@ -8,6 +8,7 @@ import (
 	"github.com/joetifa2003/mm-go"
 	"github.com/joetifa2003/mm-go/allocator"
 	"github.com/joetifa2003/mm-go/batchallocator"
+	"github.com/joetifa2003/mm-go/mmtest"
 	"github.com/joetifa2003/mm-go/typedarena"
 )
 
@@ -73,6 +74,26 @@ func linkedListFree[T any](alloc allocator.Allocator, list *LinkedList[T]) {
 
 const LINKED_LIST_SIZE = 10000
 
+func TestLinkedList(t *testing.T) {
+	const size = 1000
+
+	t.Run("managed", func(t *testing.T) {
+		benchLinkedListManaged(t, size)
+	})
+
+	t.Run("CAlloc", func(t *testing.T) {
+		benchLinkedListCAlloc(t, mmtest.NewAllocator(t), size)
+	})
+
+	t.Run("BatchAllocator", func(t *testing.T) {
+		benchLinkedListBatchAllocator(t, mmtest.NewAllocator(t), size, 100)
+	})
+
+	t.Run("TypedArena", func(t *testing.T) {
+		benchLinkedListTypedArena(t, mmtest.NewAllocator(t), size, 100)
+	})
+}
+
 func BenchmarkLinkedListManaged(b *testing.B) {
 	for range b.N {
 		benchLinkedListManaged(b, LINKED_LIST_SIZE)
@@ -80,9 +101,13 @@ func BenchmarkLinkedListManaged(b *testing.B) {
 	}
 }
 
+// The benchmarks use the C allocator directly, mmtest records a stack trace for every allocation,
+// TestLinkedList checks the same code for leaks.
 func BenchmarkLinkedListCAlloc(b *testing.B) {
 	for range b.N {
-		benchLinkedListCAlloc(b, LINKED_LIST_SIZE)
+		alloc := allocator.NewC()
+		benchLinkedListCAlloc(b, alloc, LINKED_LIST_SIZE)
+		alloc.Destroy()
 	}
 }
 
@@ -90,7 +115,9 @@ func BenchmarkLinkedListBatchAllocator(b *testing.B) {
 	for _, bucketSize := range []int{100, 200, 500, LINKED_LIST_SIZE} {
 		b.Run(fmt.Sprintf("bucket size %d", bucketSize), func(b *testing.B) {
 			for range b.N {
-				benchLinkedListBatchAllocator(b, LINKED_LIST_SIZE, bucketSize)
+				alloc := allocator.NewC()
+				benchLinkedListBatchAllocator(b, alloc, LINKED_LIST_SIZE, bucketSize)
+				alloc.Destroy()
 			}
 		})
 	}
@@ -100,16 +127,15 @@ func BenchmarkLinkedListTypedArena(b *testing.B) {
 	for _, chunkSize := range []int{100, 200, 500, LINKED_LIST_SIZE} {
 		b.Run(fmt.Sprintf("chunk size %d", chunkSize), func(b *testing.B) {
 			for range b.N {
-				benchLinkedListTypedArena(b, LINKED_LIST_SIZE, chunkSize)
+				alloc := allocator.NewC()
+				benchLinkedListTypedArena(b, alloc, LINKED_LIST_SIZE, chunkSize)
+				alloc.Destroy()
 			}
 		})
 	}
 }
 
-func benchLinkedListTypedArena(b *testing.B, size int, chunkSize int) {
-	alloc := allocator.NewC()
-	defer alloc.Destroy()
-
+func benchLinkedListTypedArena(t testing.TB, alloc allocator.Allocator, size int, chunkSize int) {
 	arena := typedarena.New[Node[int]](alloc, chunkSize)
 	defer arena.Free()
 
@@ -120,33 +146,31 @@ func benchLinkedListTypedArena(b *testing.B, size int, chunkSize int) {
 		linkedListPushArena(arena, list, i)
 	}
 
-	assertLinkedList(b, list)
+	assertLinkedList(t, list)
 }
 
-func benchLinkedListManaged(b *testing.B, size int) {
+func benchLinkedListManaged(t testing.TB, size int) {
 	list := &LinkedList[int]{}
 	for i := range size {
 		linkedListPushManaged(list, i)
 	}
-	assertLinkedList(b, list)
+	assertLinkedList(t, list)
 }
 
-func benchLinkedListCAlloc(b *testing.B, size int) {
-	alloc := allocator.NewC()
-	defer alloc.Destroy()
-
+func benchLinkedListCAlloc(t testing.TB, alloc allocator.Allocator, size int) {
 	list := allocator.Alloc[LinkedList[int]](alloc)
+	defer allocator.Free(alloc, list)
 	defer linkedListFree(alloc, list)
 
 	for i := range size {
 		linkedListPushAlloc(alloc, list, i)
 	}
 
-	assertLinkedList(b, list)
+	assertLinkedList(t, list)
 }
 
-func benchLinkedListBatchAllocator(b *testing.B, size int, bucketSize int) {
-	alloc := batchallocator.New(allocator.NewC(),
+func benchLinkedListBatchAllocator(t testing.TB, base allocator.Allocator, size int, bucketSize int) {
+	alloc := batchallocator.New(base,
 		batchallocator.WithBucketSize(mm.SizeOf[Node[int]]()*bucketSize),
 	)
 	defer alloc.Destroy()
@@ -155,10 +179,10 @@ func benchLinkedListBatchAllocator(b *testing.B, size int, bucketSize int) {
 	for i := range size {
 		linkedListPushAlloc(alloc, list, i)
 	}
-	assertLinkedList(b, list)
+	assertLinkedList(t, list)
 }
 
-func assertLinkedList(t *testing.B, list *LinkedList[int]) {
+func assertLinkedList(t testing.TB, list *LinkedList[int]) {
 	if list.head == nil {
 		t.Fatal("list head is nil")
 	}
@@ -0,0 +1,15 @@
+// mmcheck reports misuses of mm-go, it can be run directly or using go vet.
+//
+//	mmcheck ./...
+//	go vet -vettool=$(which mmcheck) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/joetifa2003/mm-go/mmcheck"
+)
+
+func main() {
+	singlechecker.Main(mmcheck.Analyzer)
+}
@@ -0,0 +1,597 @@
+// mmcheck is a static analyzer that reports common misuses of mm-go:
+//   - containers created with New that are never freed (unless created from a batchallocator, which acts as an arena)
+//   - appending to slices that represent manually managed memory (Vector.Slice, allocator.AllocMany, etc...)
+//   - allocating types that contain go managed memory (strings, slices, maps, channels, funcs and interfaces)
+//   - freeing the same variable twice
+//
+// Pointers are not reported when allocating, because they usually point to manually managed memory,
+// use mm.SetStrictMode to check them at runtime.
+//
+// It can be run directly or used with go vet using cmd/mmcheck.
+// It's a separate module so mm-go doesn't depend on x/tools, and it needs go 1.25 or newer to build:
+//
+//	go install github.com/joetifa2003/mm-go/mmcheck/cmd/mmcheck@latest
+//	mmcheck ./...
+//	go vet -vettool=$(which mmcheck) ./...
+//
+// Constructors and allocating functions are recognized by their signatures, not by name,
+// so new containers are checked as long as they follow the same conventions.
+package mmcheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const modulePath = "github.com/joetifa2003/mm-go"
+
+// Analyzer reports misuses of mm-go
+var Analyzer = &analysis.Analyzer{
+	Name:     "mmcheck",
+	Doc:      "reports leaks, double frees, appending to manually managed slices and go managed types in manually managed memory",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// manualSlices are functions and methods that return slices representing manually managed memory
+var manualSlices = map[string][]string{
+	"allocator":  {"AllocMany", "Realloc"},
+	"vector":     {"Slice"},
+	"bytebuf":    {"Bytes"},
+	"typedarena": {"AllocMany"},
+	"scope":      {"AllocMany"},
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	insp.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		checkGoPointers(pass, n.(*ast.CallExpr))
+	})
+
+	insp.Preorder([]ast.Node{(*ast.FuncDecl)(nil), (*ast.FuncLit)(nil)}, func(n ast.Node) {
+		var body *ast.BlockStmt
+		switch fn := n.(type) {
+		case *ast.FuncDecl:
+			body = fn.Body
+		case *ast.FuncLit:
+			body = fn.Body
+		}
+		if body == nil {
+			return
+		}
+
+		checkAppend(pass, body)
+		checkLeaks(pass, body)
+		checkDoubleFree(pass, body)
+	})
+
+	return nil, nil
+}
+
+// mmFunc returns the mm-go package name and function name of the function called by call
+func mmFunc(pass *analysis.Pass, call *ast.CallExpr) (pkg string, name string, ok bool) {
+	fn, pkg, ok := mmCallee(pass, call)
+	if !ok {
+		return "", "", false
+	}
+	return pkg, fn.Name(), true
+}
+
+// mmCallee returns the mm-go function called by call and its package name
+func mmCallee(pass *analysis.Pass, call *ast.CallExpr) (fn *types.Func, pkg string, ok bool) {
+	fn, isFunc := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !isFunc || fn.Pkg() == nil {
+		return nil, "", false
+	}
+
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		named := namedOf(recv.Type())
+		if named == nil || named.Obj().Pkg() == nil {
+			return nil, "", false
+		}
+		pkg, ok = mmPackage(named.Obj().Pkg().Path())
+		return fn, pkg, ok
+	}
+
+	pkg, ok = mmPackage(fn.Pkg().Path())
+	return fn, pkg, ok
+}
+
+// isConstructor reports whether fn creates a container that must be freed,
+// constructors take an allocator as the first parameter and return a value with a Free() method,
+// this includes New functions and methods like CloneInto and MoveTo.
+func isConstructor(fn *types.Func) bool {
+	sig := fn.Type().(*types.Signature)
+	if sig.Params().Len() == 0 || !isAllocator(sig.Params().At(0).Type()) || sig.Results().Len() != 1 {
+		return false
+	}
+
+	free, _, _ := types.LookupFieldOrMethod(sig.Results().At(0).Type(), false, nil, "Free")
+	freeFn, ok := free.(*types.Func)
+	if !ok {
+		return false
+	}
+
+	freeSig := freeFn.Type().(*types.Signature)
+	return freeSig.Params().Len() == 0 && freeSig.Results().Len() == 0
+}
+
+// isAllocating reports whether fn allocates its type arguments in manually managed memory,
+// these are generic functions that return a pointer, a slice or an mm-go type of their type parameters,
+// like allocator.Alloc, vector.New and rc.New.
+func isAllocating(fn *types.Func) bool {
+	sig := fn.Type().(*types.Signature)
+	if sig.Recv() != nil || sig.TypeParams().Len() == 0 {
+		return false
+	}
+
+	for i := range sig.Results().Len() {
+		switch t := sig.Results().At(i).Type().(type) {
+		case *types.Pointer:
+			if containsTypeParam(t.Elem()) {
+				return true
+			}
+		case *types.Slice:
+			if containsTypeParam(t.Elem()) {
+				return true
+			}
+		case *types.Named:
+			if containsTypeParam(t) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func containsTypeParam(t types.Type) bool {
+	switch t := t.(type) {
+	case *types.TypeParam:
+		return true
+	case *types.Pointer:
+		return containsTypeParam(t.Elem())
+	case *types.Slice:
+		return containsTypeParam(t.Elem())
+	case *types.Named:
+		for i := range t.TypeArgs().Len() {
+			if containsTypeParam(t.TypeArgs().At(i)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isAllocator(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == modulePath+"/allocator" && named.Obj().Name() == "Allocator"
+}
+
+func mmPackage(path string) (string, bool) {
+	if path == modulePath {
+		return "mm", true
+	}
+
+	name, ok := strings.CutPrefix(path, modulePath+"/")
+	return name, ok
+}
+
+func namedOf(t types.Type) *types.Named {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, _ := t.(*types.Named)
+	return named
+}
+
+func isOneOf(set map[string][]string, pkg, name string) bool {
+	for _, n := range set[pkg] {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isConstructorCall(pass *analysis.Pass, call *ast.CallExpr) bool {
+	fn, _, ok := mmCallee(pass, call)
+	return ok && isConstructor(fn)
+}
+
+func isMMCall(pass *analysis.Pass, expr ast.Expr, set map[string][]string) bool {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+
+	pkg, name, ok := mmFunc(pass, call)
+	return ok && isOneOf(set, pkg, name)
+}
+
+func objOf(pass *analysis.Pass, expr ast.Expr) types.Object {
+	ident, ok := ast.Unparen(expr).(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	if v, ok := pass.TypesInfo.ObjectOf(ident).(*types.Var); ok {
+		return v
+	}
+	return nil
+}
+
+// checkGoPointers reports allocating types that contain go managed memory
+func checkGoPointers(pass *analysis.Pass, call *ast.CallExpr) {
+	fn, pkg, ok := mmCallee(pass, call)
+	if !ok || !isAllocating(fn) {
+		return
+	}
+	name := fn.Name()
+
+	var ident *ast.Ident
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.IndexExpr:
+		ident = calleeIdent(fun.X)
+	case *ast.IndexListExpr:
+		ident = calleeIdent(fun.X)
+	default:
+		ident = calleeIdent(fun)
+	}
+	if ident == nil {
+		return
+	}
+
+	inst, ok := pass.TypesInfo.Instances[ident]
+	if !ok {
+		return
+	}
+
+	for i := range inst.TypeArgs.Len() {
+		t := inst.TypeArgs.At(i)
+		if hasGoPointers(t, map[types.Type]bool{}) {
+			pass.Reportf(call.Pos(), "%s.%s allocates %s in manually managed memory, but it contains go managed memory that the GC can't see", pkg, name, t)
+		}
+	}
+}
+
+func calleeIdent(expr ast.Expr) *ast.Ident {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e
+	case *ast.SelectorExpr:
+		return e.Sel
+	}
+	return nil
+}
+
+func hasGoPointers(t types.Type, seen map[types.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	// type parameters are checked where they are instantiated
+	if _, ok := t.(*types.TypeParam); ok {
+		return false
+	}
+
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil {
+		if _, ok := mmPackage(named.Obj().Pkg().Path()); ok {
+			return false
+		}
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return u.Info()&types.IsString != 0
+	case *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return true
+	case *types.Array:
+		return u.Len() > 0 && hasGoPointers(u.Elem(), seen)
+	case *types.Struct:
+		for i := range u.NumFields() {
+			if hasGoPointers(u.Field(i).Type(), seen) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// checkAppend reports appending to slices that represent manually managed memory
+func checkAppend(pass *analysis.Pass, body *ast.BlockStmt) {
+	manual := map[types.Object]bool{}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.AssignStmt:
+			if len(n.Lhs) != len(n.Rhs) {
+				return true
+			}
+			for i, rhs := range n.Rhs {
+				// s = append(s, ...) keeps s as is, the append itself is reported below
+				if obj := objOf(pass, n.Lhs[i]); obj != nil && !isAppend(pass, rhs) {
+					manual[obj] = isMMCall(pass, rhs, manualSlices)
+				}
+			}
+		case *ast.ValueSpec:
+			if len(n.Names) != len(n.Values) {
+				return true
+			}
+			for i, value := range n.Values {
+				if obj := objOf(pass, n.Names[i]); obj != nil {
+					manual[obj] = isMMCall(pass, value, manualSlices)
+				}
+			}
+		case *ast.CallExpr:
+			if !isAppend(pass, n) || len(n.Args) == 0 {
+				return true
+			}
+
+			arg := n.Args[0]
+			if isMMCall(pass, arg, manualSlices) || manual[objOf(pass, arg)] {
+				pass.Reportf(n.Pos(), "append to a slice that represents manually managed memory")
+			}
+		}
+		return true
+	})
+}
+
+func isAppend(pass *analysis.Pass, expr ast.Expr) bool {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+
+	ident, ok := ast.Unparen(call.Fun).(*ast.Ident)
+	if !ok {
+		return false
+	}
+
+	_, isBuiltin := pass.TypesInfo.Uses[ident].(*types.Builtin)
+	return isBuiltin && ident.Name == "append"
+}
+
+// checkLeaks reports containers assigned to local variables that are never freed and never escape
+func checkLeaks(pass *analysis.Pass, body *ast.BlockStmt) {
+	type created struct {
+		pos   token.Pos
+		name  string
+		freed bool
+	}
+	vars := map[types.Object]*created{}
+	arenas := map[types.Object]bool{}
+
+	// collect containers and batch allocators
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.AssignStmt:
+			if n.Tok != token.DEFINE || len(n.Lhs) != len(n.Rhs) {
+				return true
+			}
+			for i, rhs := range n.Rhs {
+				obj := objOf(pass, n.Lhs[i])
+				if obj == nil {
+					continue
+				}
+
+				if isBatchAllocator(pass, rhs) {
+					arenas[obj] = true
+					continue
+				}
+
+				call, ok := ast.Unparen(rhs).(*ast.CallExpr)
+				if !ok || !isConstructorCall(pass, call) || len(call.Args) == 0 {
+					continue
+				}
+
+				alloc := call.Args[0]
+				if arenas[objOf(pass, alloc)] || isBatchAllocator(pass, alloc) {
+					continue
+				}
+
+				vars[obj] = &created{pos: call.Pos(), name: obj.Name()}
+			}
+		}
+		return true
+	})
+
+	if len(vars) == 0 {
+		return
+	}
+
+	// a container is used correctly if it's freed or escapes (returned, passed to a function, stored, captured, etc...)
+	var visit func(n ast.Node, parent ast.Node) bool
+	visit = func(n ast.Node, parent ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+
+		c, ok := vars[pass.TypesInfo.Uses[ident]]
+		if !ok {
+			return true
+		}
+
+		sel, isSel := parent.(*ast.SelectorExpr)
+		if !isSel || sel.X != ident {
+			c.freed = true // escapes
+			return true
+		}
+
+		// MoveTo frees the original container
+		if sel.Sel.Name == "Free" || sel.Sel.Name == "MoveTo" {
+			c.freed = true
+		}
+
+		return true
+	}
+	inspectWithParent(body, visit)
+
+	for _, c := range vars {
+		if !c.freed {
+			pass.Reportf(c.pos, "%s is never freed", c.name)
+		}
+	}
+}
+
+func isBatchAllocator(pass *analysis.Pass, expr ast.Expr) bool {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+
+	pkg, name, ok := mmFunc(pass, call)
+	return ok && pkg == "batchallocator" && name == "New"
+}
+
+func inspectWithParent(root ast.Node, f func(n ast.Node, parent ast.Node) bool) {
+	stack := []ast.Node{}
+	ast.Inspect(root, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+
+		var parent ast.Node
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1]
+		}
+
+		stack = append(stack, n)
+		return f(n, parent)
+	})
+}
+
+type freeCall struct {
+	pos      token.Pos
+	deferred bool
+}
+
+// checkDoubleFree reports variables freed twice in the same block,
+// or freed while a deferred free exists for the same variable.
+func checkDoubleFree(pass *analysis.Pass, body *ast.BlockStmt) {
+	assigns := map[types.Object]int{}
+	frees := map[types.Object][]freeCall{}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				if obj := objOf(pass, lhs); obj != nil {
+					assigns[obj]++
+				}
+			}
+		case *ast.BlockStmt:
+			checkBlockDoubleFree(pass, n)
+		case *ast.DeferStmt:
+			if obj := freedObj(pass, n.Call); obj != nil {
+				frees[obj] = append(frees[obj], freeCall{pos: n.Pos(), deferred: true})
+			}
+			return false
+		case *ast.ExprStmt:
+			if call, ok := n.X.(*ast.CallExpr); ok {
+				if obj := freedObj(pass, call); obj != nil {
+					frees[obj] = append(frees[obj], freeCall{pos: n.Pos()})
+				}
+			}
+		}
+		return true
+	})
+
+	for obj, calls := range frees {
+		if len(calls) < 2 || assigns[obj] > 1 {
+			continue
+		}
+
+		deferred := false
+		for _, c := range calls {
+			if c.deferred {
+				deferred = true
+			}
+		}
+		if !deferred {
+			continue
+		}
+
+		for _, c := range calls {
+			if !c.deferred {
+				pass.Reportf(c.pos, "%s is freed while a deferred free exists", obj.Name())
+			}
+		}
+	}
+}
+
+// checkBlockDoubleFree reports variables freed twice in the same block without being reassigned
+func checkBlockDoubleFree(pass *analysis.Pass, block *ast.BlockStmt) {
+	freed := map[types.Object]bool{}
+
+	for _, stmt := range block.List {
+		switch stmt := stmt.(type) {
+		case *ast.ExprStmt:
+			call, ok := stmt.X.(*ast.CallExpr)
+			if !ok {
+				continue
+			}
+
+			obj := freedObj(pass, call)
+			if obj == nil {
+				continue
+			}
+
+			if freed[obj] {
+				pass.Reportf(stmt.Pos(), "%s is freed twice", obj.Name())
+			}
+			freed[obj] = true
+		case *ast.AssignStmt:
+			for _, lhs := range stmt.Lhs {
+				delete(freed, objOf(pass, lhs))
+			}
+		}
+	}
+}
+
+// freedObj returns the variable freed by call, it handles x.Free(), alloc.Free(x), allocator.Free(a, x) and allocator.FreeMany(a, x).
+func freedObj(pass *analysis.Pass, call *ast.CallExpr) types.Object {
+	pkg, name, ok := mmFunc(pass, call)
+	if !ok {
+		return nil
+	}
+
+	if pkg == "allocator" {
+		switch {
+		case (name == "Free" || name == "FreeMany") && len(call.Args) == 2:
+			return objOf(pass, call.Args[1])
+		case name == "Free" && len(call.Args) == 1: // Allocator.Free
+			return objOf(pass, call.Args[0])
+		}
+		return nil
+	}
+
+	// freeing a box is idempotent
+	if name != "Free" || pkg == "box" {
+		return nil
+	}
+
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+
+	return objOf(pass, sel.X)
+}
@@ -0,0 +1,13 @@
+package mmcheck_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/joetifa2003/mm-go/mmcheck"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), mmcheck.Analyzer, "a")
+}
@@ -0,0 +1,168 @@
+package a
+
+import (
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/batchallocator"
+	"github.com/joetifa2003/mm-go/box"
+	"github.com/joetifa2003/mm-go/hashmap"
+	"github.com/joetifa2003/mm-go/vector"
+)
+
+type Point struct {
+	X, Y int
+}
+
+type Named struct {
+	Name string
+}
+
+type Node struct {
+	value int
+	next  *Node
+}
+
+func leaks() {
+	alloc := allocator.NewC()
+
+	v := vector.New[int](alloc) // want `v is never freed`
+	v.Push(1)
+
+	freed := vector.New[int](alloc)
+	defer freed.Free()
+
+	hm := hashmap.New[int, int](alloc) // want `hm is never freed`
+	hm.Set(1, 1)
+
+	closure := vector.New[int](alloc)
+	defer func() {
+		closure.Free()
+	}()
+}
+
+func moves() *vector.Vector[int] {
+	alloc := allocator.NewC()
+
+	scratch := vector.New[int](alloc)
+	moved := scratch.MoveTo(alloc)
+
+	clone := moved.CloneInto(alloc) // want `clone is never freed`
+	clone.Push(1)
+
+	return moved
+}
+
+func escapes() *vector.Vector[int] {
+	alloc := allocator.NewC()
+
+	returned := vector.New[int](alloc)
+
+	passed := vector.New[int](alloc)
+	consume(passed)
+
+	return returned
+}
+
+func consume(v *vector.Vector[int]) {}
+
+func arena() {
+	alloc := batchallocator.New(allocator.NewC())
+
+	v := vector.New[int](alloc)
+	v.Push(1)
+
+	v2 := vector.New[int](batchallocator.New(allocator.NewC()))
+	v2.Push(1)
+}
+
+func appends() {
+	alloc := allocator.NewC()
+
+	v := vector.New[int](alloc)
+	defer v.Free()
+
+	_ = append(v.Slice(), 1) // want `append to a slice that represents manually managed memory`
+
+	s := v.Slice()
+	s = append(s, 1) // want `append to a slice that represents manually managed memory`
+
+	heap := allocator.AllocMany[int](alloc, 2)
+	heap = append(heap, 1) // want `append to a slice that represents manually managed memory`
+
+	var goSlice []int
+	goSlice = append(goSlice, 1)
+
+	copied := append([]int{}, v.Slice()...)
+	_ = copied
+}
+
+func goPointers() {
+	alloc := allocator.NewC()
+
+	allocator.Alloc[Point](alloc)
+	allocator.Alloc[Node](alloc)
+	allocator.Alloc[Named](alloc)         // want `allocator.Alloc allocates a.Named in manually managed memory`
+	allocator.AllocMany[string](alloc, 2) // want `allocator.AllocMany allocates string in manually managed memory`
+
+	v := vector.New[[]int](alloc) // want `vector.New allocates \[\]int in manually managed memory`
+	defer v.Free()
+
+	inferred := vector.Init(alloc, "a", "b") // want `vector.Init allocates string in manually managed memory`
+	defer inferred.Free()
+
+	hm := hashmap.New[string, *vector.Vector[int]](alloc) // want `hashmap.New allocates string in manually managed memory`
+	defer hm.Free()
+
+	b := box.New(alloc, map[int]int{}) // want `box.New allocates map\[int\]int in manually managed memory`
+	defer b.Free()
+
+	raw := hashmap.NewCustom[[]byte, int](alloc, nil, nil) // want `hashmap.NewCustom allocates \[\]byte in manually managed memory`
+	defer raw.Free()
+
+	keys := hashmap.NewCustom[hashmap.BytesKey, int](alloc, hashmap.HashBytes, hashmap.EqualBytes)
+	defer keys.Free()
+}
+
+func doubleFree() {
+	alloc := allocator.NewC()
+
+	v := vector.New[int](alloc)
+	v.Free()
+	v.Free() // want `v is freed twice`
+
+	reassigned := vector.New[int](alloc)
+	reassigned.Free()
+	reassigned = vector.New[int](alloc)
+	reassigned.Free()
+
+	deferred := vector.New[int](alloc)
+	defer deferred.Free()
+	if true {
+		deferred.Free() // want `deferred is freed while a deferred free exists`
+	}
+
+	p := allocator.Alloc[int](alloc)
+	allocator.Free(alloc, p)
+	allocator.Free(alloc, p) // want `p is freed twice`
+
+	b := box.New(alloc, 1)
+	b.Free()
+	b.Free() // freeing a box is idempotent
+}
+
+type GenericNode[T any] struct {
+	value T
+	next  *GenericNode[T]
+}
+
+func generic[T any](alloc allocator.Allocator) *GenericNode[T] {
+	return allocator.Alloc[GenericNode[T]](alloc)
+}
+
+func allocatorFree(alloc allocator.Allocator) {
+	p := alloc.Alloc(8)
+	alloc.Free(p)
+	alloc.Free(p) // want `p is freed twice`
+
+	alloc.Free(nil)
+	alloc.Free(nil)
+}
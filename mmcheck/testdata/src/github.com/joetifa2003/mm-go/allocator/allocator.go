@@ -0,0 +1,19 @@
+package allocator
+
+import "unsafe"
+
+type Allocator struct{}
+
+func NewC() Allocator { return Allocator{} }
+
+func (a Allocator) Alloc(size int) unsafe.Pointer { return nil }
+
+func (a Allocator) Free(ptr unsafe.Pointer) {}
+
+func Alloc[T any](a Allocator) *T { return new(T) }
+
+func Free[T any](a Allocator, ptr *T) {}
+
+func AllocMany[T any](a Allocator, n int) []T { return make([]T, n) }
+
+func FreeMany[T any](a Allocator, slice []T) {}
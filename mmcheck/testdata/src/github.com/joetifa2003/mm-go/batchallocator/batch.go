@@ -0,0 +1,5 @@
+package batchallocator
+
+import "github.com/joetifa2003/mm-go/allocator"
+
+func New(a allocator.Allocator) allocator.Allocator { return a }
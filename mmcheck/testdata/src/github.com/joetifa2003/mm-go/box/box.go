@@ -0,0 +1,9 @@
+package box
+
+import "github.com/joetifa2003/mm-go/allocator"
+
+type Box[T any] struct{}
+
+func New[T any](alloc allocator.Allocator, value T) Box[T] { return Box[T]{} }
+
+func (b *Box[T]) Free() {}
@@ -0,0 +1,27 @@
+package hashmap
+
+import "github.com/joetifa2003/mm-go/allocator"
+
+type Hashmap[K comparable, V any] struct{}
+
+func New[K comparable, V any](alloc allocator.Allocator) *Hashmap[K, V] { return nil }
+
+func (hm *Hashmap[K, V]) Set(key K, value V) {}
+
+func (hm *Hashmap[K, V]) Free() {}
+
+type CustomHashmap[K any, V any] struct{}
+
+func NewCustom[K any, V any](alloc allocator.Allocator, hash func(key K) uint64, equal func(a, b K) bool) *CustomHashmap[K, V] {
+	return nil
+}
+
+func (hm *CustomHashmap[K, V]) Free() {}
+
+type BytesKey struct {
+	data []byte
+}
+
+func HashBytes(key BytesKey) uint64 { return 0 }
+
+func EqualBytes(a, b BytesKey) bool { return false }
@@ -0,0 +1,21 @@
+package vector
+
+import "github.com/joetifa2003/mm-go/allocator"
+
+type Vector[T any] struct{}
+
+func New[T any](alloc allocator.Allocator, args ...int) *Vector[T] { return nil }
+
+func Init[T any](alloc allocator.Allocator, values ...T) *Vector[T] { return nil }
+
+func (v *Vector[T]) Push(value T) {}
+
+func (v *Vector[T]) Slice() []T { return nil }
+
+func (v *Vector[T]) CloneInto(alloc allocator.Allocator, cloneElem ...func(T) T) *Vector[T] {
+	return nil
+}
+
+func (v *Vector[T]) MoveTo(alloc allocator.Allocator) *Vector[T] { return nil }
+
+func (v *Vector[T]) Free() {}
@@ -46,6 +46,27 @@ func (s *MMString) AppendGoString(input string) {
 	}
 }
 
+// Clone returns a copy of the string using the same allocator.
+func (s *MMString) Clone() *MMString {
+	return s.CloneInto(s.alloc)
+}
+
+// CloneInto returns a copy of the string allocated using alloc.
+func (s *MMString) CloneInto(alloc allocator.Allocator) *MMString {
+	clone := allocator.Alloc[MMString](alloc)
+	clone.Vector = s.Vector.CloneInto(alloc)
+	clone.alloc = alloc
+	return clone
+}
+
+// MoveTo copies the string into alloc and frees the original,
+// the original string must not be used afterwards.
+func (s *MMString) MoveTo(alloc allocator.Allocator) *MMString {
+	moved := s.CloneInto(alloc)
+	s.Free()
+	return moved
+}
+
 // Free frees MMString
 func (s *MMString) Free() {
 	s.Vector.Free()
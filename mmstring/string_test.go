@@ -5,12 +5,12 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
-	"github.com/joetifa2003/mm-go/allocator"
 	"github.com/joetifa2003/mm-go/mmstring"
+	"github.com/joetifa2003/mm-go/mmtest"
 )
 
 func TestString(t *testing.T) {
-	alloc := allocator.NewC()
+	alloc := mmtest.NewAllocator(t)
 	assert := assert.New(t)
 	mmString := mmstring.From(alloc, "hi")
 	defer mmString.Free()
@@ -23,3 +23,21 @@ func TestString(t *testing.T) {
 
 	assert.Equal("hi", mmString.GetGoString())
 }
+
+func TestStringClone(t *testing.T) {
+	scratch := mmtest.NewAllocator(t)
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	s := mmstring.From(scratch, "hello")
+
+	clone := s.Clone()
+	defer clone.Free()
+	clone.AppendGoString(" world")
+
+	moved := s.MoveTo(alloc)
+	defer moved.Free()
+
+	assert.Equal("hello", moved.GetGoString())
+	assert.Equal("hello world", clone.GetGoString())
+}
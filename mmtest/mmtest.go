@@ -0,0 +1,214 @@
+// mmtest provides helpers to find manual memory leaks in tests.
+// NewAllocator returns an allocator that tracks every block allocated through it,
+// and fails the test if any block is not freed when the test finishes, reporting where it was allocated.
+// Freed blocks are filled with poisonByte, and Realloc always moves the block, so using memory after it's freed reads garbage.
+package mmtest
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/joetifa2003/mm-go/allocator"
+)
+
+const (
+	maxStackDepth = 8
+	poisonByte    = 0xAA
+)
+
+type block struct {
+	size  int
+	stack string
+}
+
+type tracker struct {
+	mu     sync.Mutex
+	t      testing.TB
+	alloc  allocator.Allocator
+	blocks map[unsafe.Pointer]block
+	pinner runtime.Pinner
+}
+
+// NewAllocator returns a tracking allocator backed by the C allocator,
+// the test fails if any memory allocated by it is not freed when the test and all its subtests complete.
+func NewAllocator(t testing.TB) allocator.Allocator {
+	t.Helper()
+
+	tr := newTracker(t)
+	t.Cleanup(func() {
+		tr.check("leaked")
+		tr.destroy()
+	})
+
+	return tr.allocator()
+}
+
+// AssertBalanced runs f with a tracking allocator,
+// and fails the test if f doesn't free all memory allocated using the allocator before returning.
+func AssertBalanced(t testing.TB, f func(alloc allocator.Allocator)) {
+	t.Helper()
+
+	tr := newTracker(t)
+	defer tr.destroy()
+
+	f(tr.allocator())
+	tr.check("not freed by AssertBalanced function")
+}
+
+// newTracker creates a tracker pinned until destroy,
+// it's the state of the allocator so it's stored in the manually managed memory allocated by it.
+func newTracker(t testing.TB) *tracker {
+	tr := &tracker{
+		t:      t,
+		alloc:  allocator.NewC(),
+		blocks: map[unsafe.Pointer]block{},
+	}
+	tr.pinner.Pin(tr)
+
+	return tr
+}
+
+func (tr *tracker) destroy() {
+	tr.alloc.Destroy()
+	tr.pinner.Unpin()
+}
+
+// allocator returns an allocator that uses tr as its state
+func (tr *tracker) allocator() allocator.Allocator {
+	return allocator.NewAllocator(
+		unsafe.Pointer(tr),
+		trackerAlloc,
+		trackerFree,
+		trackerRealloc,
+		trackerDestroy,
+	)
+}
+
+func (tr *tracker) check(msg string) {
+	tr.t.Helper()
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	if len(tr.blocks) == 0 {
+		return
+	}
+
+	leaks := make([]string, 0, len(tr.blocks))
+	total := 0
+	for _, b := range tr.blocks {
+		total += b.size
+		leaks = append(leaks, fmt.Sprintf("%d bytes allocated at:\n%s", b.size, b.stack))
+	}
+	sort.Strings(leaks)
+
+	tr.t.Errorf("%d blocks (%d bytes) %s:\n%s", len(tr.blocks), total, msg, strings.Join(leaks, "\n"))
+}
+
+func trackerAlloc(a unsafe.Pointer, size int) unsafe.Pointer {
+	tr := (*tracker)(a)
+	ptr := tr.alloc.Alloc(size)
+
+	tr.mu.Lock()
+	tr.blocks[ptr] = block{size: size, stack: callers()}
+	tr.mu.Unlock()
+
+	return ptr
+}
+
+func trackerFree(a unsafe.Pointer, ptr unsafe.Pointer) {
+	tr := (*tracker)(a)
+
+	tr.mu.Lock()
+	b, ok := tr.blocks[ptr]
+	delete(tr.blocks, ptr)
+	tr.mu.Unlock()
+
+	if !ok {
+		tr.t.Errorf("free of a pointer that is not allocated (double free?) at:\n%s", callers())
+		return
+	}
+
+	poison(ptr, b.size)
+	tr.alloc.Free(ptr)
+}
+
+func trackerRealloc(a unsafe.Pointer, ptr unsafe.Pointer, size int) unsafe.Pointer {
+	tr := (*tracker)(a)
+
+	tr.mu.Lock()
+	b, ok := tr.blocks[ptr]
+	delete(tr.blocks, ptr)
+	tr.mu.Unlock()
+
+	if !ok {
+		tr.t.Errorf("realloc of a pointer that is not allocated (use after free?) at:\n%s", callers())
+		return trackerAlloc(a, size)
+	}
+
+	// always move the block, so pointers to the old block are caught even if the C allocator could grow it in place
+	newPtr := tr.alloc.Alloc(size)
+	copy(unsafe.Slice((*byte)(newPtr), size), unsafe.Slice((*byte)(ptr), min(b.size, size)))
+	poison(ptr, b.size)
+	tr.alloc.Free(ptr)
+
+	tr.mu.Lock()
+	tr.blocks[newPtr] = block{size: size, stack: callers()}
+	tr.mu.Unlock()
+
+	return newPtr
+}
+
+func trackerDestroy(a unsafe.Pointer) {}
+
+func poison(ptr unsafe.Pointer, size int) {
+	data := unsafe.Slice((*byte)(ptr), size)
+	for i := range data {
+		data[i] = poisonByte
+	}
+}
+
+// callers returns the stack of the caller, skipping frames inside the allocator and mmtest packages
+func callers() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var sb strings.Builder
+	depth := 0
+	for {
+		frame, more := frames.Next()
+		if strings.HasPrefix(frame.Function, "testing.") {
+			break
+		}
+
+		if !isInternalFrame(frame.Function) {
+			fmt.Fprintf(&sb, "\t%s\n\t\t%s:%d\n", frame.Function, frame.File, frame.Line)
+			depth++
+		}
+
+		if !more || depth == maxStackDepth {
+			break
+		}
+	}
+
+	return sb.String()
+}
+
+func isInternalFrame(function string) bool {
+	for _, pkg := range []string{
+		"github.com/joetifa2003/mm-go/allocator.",
+		"github.com/joetifa2003/mm-go/mmtest.",
+		"runtime.",
+	} {
+		if strings.HasPrefix(function, pkg) {
+			return true
+		}
+	}
+	return false
+}
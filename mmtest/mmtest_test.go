@@ -0,0 +1,109 @@
+package mmtest_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/mmtest"
+	"github.com/joetifa2003/mm-go/vector"
+)
+
+// recorder records errors instead of failing the test
+type recorder struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Cleanup(f func()) {
+	r.cleanups = append(r.cleanups, f)
+}
+
+func (r *recorder) runCleanups() {
+	for i := len(r.cleanups) - 1; i >= 0; i-- {
+		r.cleanups[i]()
+	}
+}
+
+func leakVector(alloc allocator.Allocator) {
+	v := vector.New[int](alloc)
+	v.Push(1)
+}
+
+func TestNewAllocator(t *testing.T) {
+	assert := assert.New(t)
+
+	r := &recorder{TB: t}
+	alloc := mmtest.NewAllocator(r)
+
+	v := vector.New[int](alloc)
+	v.Push(1)
+	v.Push(2) // realloc
+	v.Free()
+
+	r.runCleanups()
+	assert.Empty(r.errors)
+
+	r = &recorder{TB: t}
+	alloc = mmtest.NewAllocator(r)
+	leakVector(alloc)
+	r.runCleanups()
+
+	assert.Len(r.errors, 1)
+	assert.Contains(r.errors[0], "2 blocks")
+	assert.Contains(r.errors[0], "leaked")
+	assert.Contains(r.errors[0], "mmtest_test.leakVector")
+}
+
+func TestDoubleFree(t *testing.T) {
+	assert := assert.New(t)
+
+	r := &recorder{TB: t}
+	alloc := mmtest.NewAllocator(r)
+
+	ptr := allocator.Alloc[int](alloc)
+	allocator.Free(alloc, ptr)
+	allocator.Free(alloc, ptr)
+
+	assert.Len(r.errors, 1)
+	assert.Contains(r.errors[0], "double free")
+	r.runCleanups()
+}
+
+func TestAssertBalanced(t *testing.T) {
+	assert := assert.New(t)
+
+	mmtest.AssertBalanced(t, func(alloc allocator.Allocator) {
+		v := vector.New[int](alloc)
+		defer v.Free()
+		v.Push(1)
+	})
+
+	r := &recorder{TB: t}
+	mmtest.AssertBalanced(r, leakVector)
+	assert.Len(r.errors, 1)
+	assert.Contains(r.errors[0], "not freed by AssertBalanced function")
+}
+
+func TestNewAllocatorPoison(t *testing.T) {
+	assert := assert.New(t)
+	alloc := mmtest.NewAllocator(t)
+
+	old := allocator.AllocMany[byte](alloc, 4)
+	copy(old, "abcd")
+
+	grown := allocator.Realloc(alloc, old, 8)
+	assert.Equal("abcd", string(grown[:4]))
+	assert.NotEqual(&old[0], &grown[0]) // always moved
+
+	allocator.FreeMany(alloc, grown)
+}
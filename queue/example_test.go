@@ -0,0 +1,34 @@
+package queue_test
+
+import (
+	"fmt"
+
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/queue"
+)
+
+func Example() {
+	alloc := allocator.NewC()
+	defer alloc.Destroy()
+
+	q := queue.NewSPSC[int](alloc, 16)
+	defer q.Free()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 3 {
+			q.Enqueue(i)
+		}
+	}()
+
+	for range 3 {
+		fmt.Println(q.Dequeue())
+	}
+	<-done
+
+	// Output:
+	// 0
+	// 1
+	// 2
+}
@@ -0,0 +1,149 @@
+package queue
+
+import (
+	"runtime"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/joetifa2003/mm-go"
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/internal/debug"
+)
+
+type cell[T any] struct {
+	// sequence is equal to the position of the cell when it's free to enqueue at that position,
+	// and to the position + 1 when it holds the value enqueued at that position.
+	sequence atomic.Uint64
+	value    T
+}
+
+// MPMC a bounded multi-producer/multi-consumer lock-free queue,
+// based on Dmitry Vyukov's bounded MPMC queue.
+type MPMC[T any] struct {
+	_          cacheLinePad
+	enqueuePos atomic.Uint64
+	_          cacheLinePad
+	dequeuePos atomic.Uint64
+	_          cacheLinePad
+
+	cells []cell[T] `mm:"manual"`
+	mask  uint64
+	alloc allocator.Allocator
+}
+
+// NewMPMC creates a new MPMC queue that can hold at least capacity values
+func NewMPMC[T any](alloc allocator.Allocator, capacity int) *MPMC[T] {
+	size := roundCapacity(capacity)
+	mm.CheckGoPointers[T]()
+
+	q := allocator.Alloc[MPMC[T]](alloc)
+	q.cells = allocator.AllocMany[cell[T]](alloc, size)
+	for i := range q.cells {
+		q.cells[i].sequence.Store(uint64(i))
+	}
+	q.mask = uint64(size - 1)
+	q.alloc = alloc
+	debug.Track(unsafe.Pointer(q))
+
+	return q
+}
+
+// TryEnqueue enqueues value T, returns false if the queue is full.
+func (q *MPMC[T]) TryEnqueue(value T) bool {
+	q.check()
+
+	pos := q.enqueuePos.Load()
+	for {
+		c := &q.cells[pos&q.mask]
+		diff := int64(c.sequence.Load() - pos)
+
+		switch {
+		case diff == 0:
+			if q.enqueuePos.CompareAndSwap(pos, pos+1) {
+				c.value = value
+				c.sequence.Store(pos + 1)
+				return true
+			}
+			pos = q.enqueuePos.Load()
+		case diff < 0:
+			return false // the cell still holds a value from the previous lap
+		default:
+			pos = q.enqueuePos.Load() // another producer took this position
+		}
+	}
+}
+
+// TryDequeue dequeues value T, returns false if the queue is empty.
+func (q *MPMC[T]) TryDequeue() (T, bool) {
+	q.check()
+
+	pos := q.dequeuePos.Load()
+	for {
+		c := &q.cells[pos&q.mask]
+		diff := int64(c.sequence.Load() - (pos + 1))
+
+		switch {
+		case diff == 0:
+			if q.dequeuePos.CompareAndSwap(pos, pos+1) {
+				value := c.value
+				c.sequence.Store(pos + q.mask + 1)
+				return value, true
+			}
+			pos = q.dequeuePos.Load()
+		case diff < 0:
+			return mm.Zero[T](), false // the value at this position is not enqueued yet
+		default:
+			pos = q.dequeuePos.Load() // another consumer took this position
+		}
+	}
+}
+
+// Enqueue enqueues value T, waits until the queue is not full.
+func (q *MPMC[T]) Enqueue(value T) {
+	for !q.TryEnqueue(value) {
+		runtime.Gosched()
+	}
+}
+
+// Dequeue dequeues value T, waits until the queue is not empty.
+func (q *MPMC[T]) Dequeue() T {
+	for {
+		if value, ok := q.TryDequeue(); ok {
+			return value
+		}
+		runtime.Gosched()
+	}
+}
+
+// Len returns the number of values in the queue,
+// it's only a snapshot if the queue is used concurrently.
+func (q *MPMC[T]) Len() int {
+	q.check()
+
+	dequeuePos := q.dequeuePos.Load()
+	enqueuePos := q.enqueuePos.Load()
+	if enqueuePos < dequeuePos {
+		return 0
+	}
+	return int(min(enqueuePos-dequeuePos, q.mask+1))
+}
+
+// Cap returns the number of values the queue can hold
+func (q *MPMC[T]) Cap() int {
+	q.check()
+	return len(q.cells)
+}
+
+// Free frees the queue, it must not be used concurrently.
+func (q *MPMC[T]) Free() {
+	debug.Untrack(unsafe.Pointer(q), "queue")
+
+	alloc := q.alloc
+	allocator.FreeMany(alloc, q.cells)
+	allocator.Free(alloc, q)
+}
+
+// check panics if the queue is freed, only in debug builds (mmdebug build tag)
+func (q *MPMC[T]) check() {
+	debug.CheckLive(unsafe.Pointer(q), "queue")
+}
@@ -0,0 +1,138 @@
+// queue provides bounded lock-free queues to pass values between goroutines without the GC cost of channels,
+// the slots of the queues are allocated from an allocator.
+//
+//   - SPSC is a single-producer/single-consumer queue, only one goroutine can enqueue and only one goroutine can dequeue at a time.
+//   - MPMC is a multi-producer/multi-consumer queue, any number of goroutines can enqueue and dequeue concurrently.
+//
+// The capacity of the queues is rounded up to a power of two.
+// TryEnqueue and TryDequeue never block, Enqueue and Dequeue spin (yielding the processor) until they succeed.
+package queue
+
+import (
+	"fmt"
+	"math/bits"
+	"runtime"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/joetifa2003/mm-go"
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/internal/debug"
+)
+
+const cacheLineSize = 64
+
+// cacheLinePad prevents false sharing between fields written by different goroutines
+type cacheLinePad [cacheLineSize]byte
+
+// SPSC a bounded single-producer/single-consumer lock-free queue
+type SPSC[T any] struct {
+	_    cacheLinePad
+	head atomic.Uint64 // Position of the next value to dequeue, written by the consumer
+	_    cacheLinePad
+	tail atomic.Uint64 // Position of the next value to enqueue, written by the producer
+	_    cacheLinePad
+
+	data  []T `mm:"manual"`
+	mask  uint64
+	alloc allocator.Allocator
+}
+
+// NewSPSC creates a new SPSC queue that can hold at least capacity values
+func NewSPSC[T any](alloc allocator.Allocator, capacity int) *SPSC[T] {
+	size := roundCapacity(capacity)
+	mm.CheckGoPointers[T]()
+
+	q := allocator.Alloc[SPSC[T]](alloc)
+	q.data = allocator.AllocMany[T](alloc, size)
+	q.mask = uint64(size - 1)
+	q.alloc = alloc
+	debug.Track(unsafe.Pointer(q))
+
+	return q
+}
+
+// TryEnqueue enqueues value T, returns false if the queue is full.
+// Only one goroutine can enqueue at a time.
+func (q *SPSC[T]) TryEnqueue(value T) bool {
+	q.check()
+
+	tail := q.tail.Load()
+	if tail-q.head.Load() > q.mask {
+		return false
+	}
+
+	q.data[tail&q.mask] = value
+	q.tail.Store(tail + 1)
+	return true
+}
+
+// TryDequeue dequeues value T, returns false if the queue is empty.
+// Only one goroutine can dequeue at a time.
+func (q *SPSC[T]) TryDequeue() (T, bool) {
+	q.check()
+
+	head := q.head.Load()
+	if head == q.tail.Load() {
+		return mm.Zero[T](), false
+	}
+
+	value := q.data[head&q.mask]
+	q.head.Store(head + 1)
+	return value, true
+}
+
+// Enqueue enqueues value T, waits until the queue is not full.
+func (q *SPSC[T]) Enqueue(value T) {
+	for !q.TryEnqueue(value) {
+		runtime.Gosched()
+	}
+}
+
+// Dequeue dequeues value T, waits until the queue is not empty.
+func (q *SPSC[T]) Dequeue() T {
+	for {
+		if value, ok := q.TryDequeue(); ok {
+			return value
+		}
+		runtime.Gosched()
+	}
+}
+
+// Len returns the number of values in the queue,
+// it's only a snapshot if the queue is used concurrently.
+func (q *SPSC[T]) Len() int {
+	q.check()
+
+	head := q.head.Load()
+	return int(q.tail.Load() - head)
+}
+
+// Cap returns the number of values the queue can hold
+func (q *SPSC[T]) Cap() int {
+	q.check()
+	return len(q.data)
+}
+
+// Free frees the queue, it must not be used concurrently.
+func (q *SPSC[T]) Free() {
+	debug.Untrack(unsafe.Pointer(q), "queue")
+
+	alloc := q.alloc
+	allocator.FreeMany(alloc, q.data)
+	allocator.Free(alloc, q)
+}
+
+// check panics if the queue is freed, only in debug builds (mmdebug build tag)
+func (q *SPSC[T]) check() {
+	debug.CheckLive(unsafe.Pointer(q), "queue")
+}
+
+// roundCapacity rounds capacity up to a power of two
+func roundCapacity(capacity int) int {
+	if capacity < 1 {
+		panic(fmt.Sprintf("cannot create a queue with capacity %d", capacity))
+	}
+
+	return 1 << bits.Len(uint(capacity-1))
+}
@@ -0,0 +1,170 @@
+package queue_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/mmtest"
+	"github.com/joetifa2003/mm-go/queue"
+)
+
+type boundedQueue[T any] interface {
+	TryEnqueue(value T) bool
+	TryDequeue() (T, bool)
+	Enqueue(value T)
+	Dequeue() T
+	Len() int
+	Cap() int
+	Free()
+}
+
+func testBounded(t *testing.T, newQueue func(alloc allocator.Allocator, capacity int) boundedQueue[int]) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	q := newQueue(alloc, 3)
+	defer q.Free()
+
+	assert.Equal(4, q.Cap())
+
+	_, ok := q.TryDequeue()
+	assert.False(ok)
+
+	// wrap around the slots a few times
+	for lap := range 3 {
+		for i := range 4 {
+			assert.True(q.TryEnqueue(lap*4 + i))
+		}
+		assert.False(q.TryEnqueue(-1))
+		assert.Equal(4, q.Len())
+
+		for i := range 4 {
+			value, ok := q.TryDequeue()
+			assert.True(ok)
+			assert.Equal(lap*4+i, value)
+		}
+		_, ok = q.TryDequeue()
+		assert.False(ok)
+		assert.Equal(0, q.Len())
+	}
+}
+
+func TestSPSC(t *testing.T) {
+	testBounded(t, func(alloc allocator.Allocator, capacity int) boundedQueue[int] {
+		return queue.NewSPSC[int](alloc, capacity)
+	})
+}
+
+func TestMPMC(t *testing.T) {
+	testBounded(t, func(alloc allocator.Allocator, capacity int) boundedQueue[int] {
+		return queue.NewMPMC[int](alloc, capacity)
+	})
+}
+
+func TestInvalidCapacity(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+
+	assert.Panics(t, func() {
+		queue.NewSPSC[int](alloc, 0)
+	})
+	assert.Panics(t, func() {
+		queue.NewMPMC[int](alloc, -1)
+	})
+}
+
+const messages = 100000
+
+func TestSPSCConcurrent(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+
+	q := queue.NewSPSC[int](alloc, 64)
+	defer q.Free()
+
+	go func() {
+		for i := range messages {
+			q.Enqueue(i)
+		}
+	}()
+
+	for i := range messages {
+		if value := q.Dequeue(); value != i {
+			t.Fatalf("expected %d, got %d", i, value)
+		}
+	}
+}
+
+func TestMPMCConcurrent(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	const producers = 4
+	const consumers = 4
+
+	q := queue.NewMPMC[int](alloc, 64)
+	defer q.Free()
+
+	var wg sync.WaitGroup
+	for p := range producers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := p; i < messages; i += producers {
+				q.Enqueue(i)
+			}
+		}()
+	}
+
+	received := make([][]int, consumers)
+	var consumersWg sync.WaitGroup
+	for c := range consumers {
+		consumersWg.Add(1)
+		go func() {
+			defer consumersWg.Done()
+			for range messages / consumers {
+				received[c] = append(received[c], q.Dequeue())
+			}
+		}()
+	}
+
+	wg.Wait()
+	consumersWg.Wait()
+
+	seen := make([]bool, messages)
+	for _, values := range received {
+		for _, value := range values {
+			assert.False(seen[value], "received %d twice", value)
+			seen[value] = true
+		}
+	}
+	assert.NotContains(seen, false)
+	assert.Equal(0, q.Len())
+}
+
+func BenchmarkMPMC(b *testing.B) {
+	alloc := allocator.NewC()
+	defer alloc.Destroy()
+
+	q := queue.NewMPMC[int](alloc, 1024)
+	defer q.Free()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			q.Enqueue(1)
+			q.Dequeue()
+		}
+	})
+}
+
+func BenchmarkChannel(b *testing.B) {
+	ch := make(chan int, 1024)
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			ch <- 1
+			<-ch
+		}
+	})
+}
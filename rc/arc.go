@@ -0,0 +1,144 @@
+package rc
+
+import (
+	"sync/atomic"
+
+	"github.com/joetifa2003/mm-go"
+	"github.com/joetifa2003/mm-go/allocator"
+)
+
+type arcBox[T any] struct {
+	strong     atomic.Int64
+	weak       atomic.Int64 // Number of weak references, plus one shared by all strong references
+	destructor func(*T)     `mm:"static"`
+	alloc      allocator.Allocator
+	value      T
+}
+
+// Arc is an atomic reference counted pointer to T, it's safe to clone and release from multiple goroutines.
+// Access to the value itself is not synchronized.
+type Arc[T any] struct {
+	box *arcBox[T]
+}
+
+// WeakArc is a weak reference to a value owned by Arc, it doesn't keep the value alive.
+type WeakArc[T any] struct {
+	box *arcBox[T]
+}
+
+// NewArc allocates T using alloc with a strong count of 1
+func NewArc[T any](alloc allocator.Allocator, value T) Arc[T] {
+	return NewArcWithDestructor(alloc, value, nil)
+}
+
+// NewArcWithDestructor allocates T using alloc with a strong count of 1,
+// destructor is called when the strong count drops to zero, it can be used to free resources owned by T.
+// destructor is stored in manually managed memory, see [mm] for the functions that can be stored.
+func NewArcWithDestructor[T any](alloc allocator.Allocator, value T, destructor func(*T)) Arc[T] {
+	mm.CheckGoPointers[T]()
+
+	box := allocator.Alloc[arcBox[T]](alloc)
+	box.strong.Store(1)
+	box.weak.Store(1)
+	box.destructor = destructor
+	box.alloc = alloc
+	box.value = value
+
+	return Arc[T]{box: box}
+}
+
+// Get returns a pointer to the value
+// CAUTION: the pointer must not outlive the reference
+func (a Arc[T]) Get() *T {
+	if a.box == nil {
+		panic(releasedMsg)
+	}
+
+	return &a.box.value
+}
+
+// Clone returns a new strong reference to the value and increments the strong count
+func (a Arc[T]) Clone() Arc[T] {
+	if a.box == nil {
+		panic(releasedMsg)
+	}
+
+	a.box.strong.Add(1)
+	return a
+}
+
+// Downgrade returns a new weak reference to the value
+func (a Arc[T]) Downgrade() WeakArc[T] {
+	if a.box == nil {
+		panic(releasedMsg)
+	}
+
+	a.box.weak.Add(1)
+	return WeakArc[T]{box: a.box}
+}
+
+// StrongCount returns the number of strong references to the value
+func (a Arc[T]) StrongCount() int {
+	return int(a.box.strong.Load())
+}
+
+// WeakCount returns the number of weak references to the value
+func (a Arc[T]) WeakCount() int {
+	return int(a.box.weak.Load()) - 1
+}
+
+// Release releases the reference, the value is destroyed if it's the last strong reference.
+// The reference must not be used after calling Release.
+func (a *Arc[T]) Release() {
+	if a.box == nil {
+		return
+	}
+
+	box := a.box
+	a.box = nil
+
+	if box.strong.Add(-1) != 0 {
+		return
+	}
+
+	if box.destructor != nil {
+		box.destructor(&box.value)
+	}
+	releaseWeakArc(box)
+}
+
+// Upgrade returns a new strong reference to the value if it's still alive
+func (w WeakArc[T]) Upgrade() (Arc[T], bool) {
+	if w.box == nil {
+		return Arc[T]{}, false
+	}
+
+	for {
+		strong := w.box.strong.Load()
+		if strong == 0 {
+			return Arc[T]{}, false
+		}
+
+		if w.box.strong.CompareAndSwap(strong, strong+1) {
+			return Arc[T]{box: w.box}, true
+		}
+	}
+}
+
+// Release releases the weak reference, the memory is freed if there is no references left.
+// The reference must not be used after calling Release.
+func (w *WeakArc[T]) Release() {
+	if w.box == nil {
+		return
+	}
+
+	box := w.box
+	w.box = nil
+	releaseWeakArc(box)
+}
+
+func releaseWeakArc[T any](box *arcBox[T]) {
+	if box.weak.Add(-1) == 0 {
+		allocator.Free(box.alloc, box)
+	}
+}
@@ -0,0 +1,32 @@
+package rc_test
+
+import (
+	"fmt"
+
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/rc"
+	"github.com/joetifa2003/mm-go/vector"
+)
+
+func Example() {
+	alloc := allocator.NewC()
+	defer alloc.Destroy()
+
+	buf := rc.NewWithDestructor(alloc, vector.Init(alloc, 1, 2, 3), func(v **vector.Vector[int]) {
+		fmt.Println("freeing buffer")
+		(*v).Free()
+	})
+
+	shared := buf.Clone() // shared with another subsystem
+	fmt.Println(buf.StrongCount())
+
+	buf.Release()
+	fmt.Println((*shared.Get()).Slice())
+
+	shared.Release() // last reference, the destructor is called
+
+	// Output:
+	// 2
+	// [1 2 3]
+	// freeing buffer
+}
@@ -0,0 +1,142 @@
+// rc provides reference counted pointers to manually managed memory.
+// Rc is non-atomic and must only be used from one goroutine at a time, Arc uses atomic counters and is goroutine-safe.
+// The counters are stored alongside the value in a single allocation,
+// the value is destroyed when the last strong reference is released,
+// and the memory is freed when the last weak reference is released.
+package rc
+
+import (
+	"github.com/joetifa2003/mm-go"
+	"github.com/joetifa2003/mm-go/allocator"
+)
+
+const releasedMsg = "cannot use a released reference"
+
+type rcBox[T any] struct {
+	strong     int
+	weak       int      // Number of weak references, plus one shared by all strong references
+	destructor func(*T) `mm:"static"`
+	alloc      allocator.Allocator
+	value      T
+}
+
+// Rc is a non-atomic reference counted pointer to T.
+type Rc[T any] struct {
+	box *rcBox[T]
+}
+
+// Weak is a weak reference to a value owned by Rc, it doesn't keep the value alive.
+type Weak[T any] struct {
+	box *rcBox[T]
+}
+
+// New allocates T using alloc with a strong count of 1
+func New[T any](alloc allocator.Allocator, value T) Rc[T] {
+	return NewWithDestructor(alloc, value, nil)
+}
+
+// NewWithDestructor allocates T using alloc with a strong count of 1,
+// destructor is called when the strong count drops to zero, it can be used to free resources owned by T.
+// destructor is stored in manually managed memory, see [mm] for the functions that can be stored.
+func NewWithDestructor[T any](alloc allocator.Allocator, value T, destructor func(*T)) Rc[T] {
+	mm.CheckGoPointers[T]()
+
+	box := allocator.Alloc[rcBox[T]](alloc)
+	box.strong = 1
+	box.weak = 1
+	box.destructor = destructor
+	box.alloc = alloc
+	box.value = value
+
+	return Rc[T]{box: box}
+}
+
+// Get returns a pointer to the value
+// CAUTION: the pointer must not outlive the reference
+func (r Rc[T]) Get() *T {
+	if r.box == nil {
+		panic(releasedMsg)
+	}
+
+	return &r.box.value
+}
+
+// Clone returns a new strong reference to the value and increments the strong count
+func (r Rc[T]) Clone() Rc[T] {
+	if r.box == nil {
+		panic(releasedMsg)
+	}
+
+	r.box.strong++
+	return r
+}
+
+// Downgrade returns a new weak reference to the value
+func (r Rc[T]) Downgrade() Weak[T] {
+	if r.box == nil {
+		panic(releasedMsg)
+	}
+
+	r.box.weak++
+	return Weak[T]{box: r.box}
+}
+
+// StrongCount returns the number of strong references to the value
+func (r Rc[T]) StrongCount() int {
+	return r.box.strong
+}
+
+// WeakCount returns the number of weak references to the value
+func (r Rc[T]) WeakCount() int {
+	return r.box.weak - 1
+}
+
+// Release releases the reference, the value is destroyed if it's the last strong reference.
+// The reference must not be used after calling Release.
+func (r *Rc[T]) Release() {
+	if r.box == nil {
+		return
+	}
+
+	box := r.box
+	r.box = nil
+
+	box.strong--
+	if box.strong != 0 {
+		return
+	}
+
+	if box.destructor != nil {
+		box.destructor(&box.value)
+	}
+	releaseWeak(box)
+}
+
+// Upgrade returns a new strong reference to the value if it's still alive
+func (w Weak[T]) Upgrade() (Rc[T], bool) {
+	if w.box == nil || w.box.strong == 0 {
+		return Rc[T]{}, false
+	}
+
+	w.box.strong++
+	return Rc[T]{box: w.box}, true
+}
+
+// Release releases the weak reference, the memory is freed if there is no references left.
+// The reference must not be used after calling Release.
+func (w *Weak[T]) Release() {
+	if w.box == nil {
+		return
+	}
+
+	box := w.box
+	w.box = nil
+	releaseWeak(box)
+}
+
+func releaseWeak[T any](box *rcBox[T]) {
+	box.weak--
+	if box.weak == 0 {
+		allocator.Free(box.alloc, box)
+	}
+}
@@ -0,0 +1,97 @@
+package rc_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/joetifa2003/mm-go/mmtest"
+	"github.com/joetifa2003/mm-go/rc"
+	"github.com/joetifa2003/mm-go/vector"
+)
+
+// destroyed counts destructor calls, destructors are stored in manually managed memory so they can't capture it
+var destroyed int
+
+func freeVector(v **vector.Vector[int]) {
+	(*v).Free()
+	destroyed++
+}
+
+func countDestroyed(*int) {
+	destroyed++
+}
+
+func TestRc(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	destroyed = 0
+	r := rc.NewWithDestructor(alloc, vector.Init(alloc, 1, 2, 3), freeVector)
+
+	r2 := r.Clone()
+	assert.Equal(2, r.StrongCount())
+	assert.Equal(3, (*r2.Get()).Len())
+
+	w := r.Downgrade()
+	assert.Equal(1, r.WeakCount())
+
+	r.Release()
+	r.Release() // releasing twice does nothing
+	assert.Equal(0, destroyed)
+	assert.Panics(func() {
+		r.Get()
+	})
+
+	upgraded, ok := w.Upgrade()
+	assert.True(ok)
+	assert.Equal(2, upgraded.StrongCount())
+	upgraded.Release()
+
+	r2.Release()
+	assert.Equal(1, destroyed)
+
+	_, ok = w.Upgrade()
+	assert.False(ok)
+
+	w.Release() // frees the memory
+}
+
+func TestArc(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	destroyed = 0
+	a := rc.NewArcWithDestructor(alloc, 0, countDestroyed)
+	w := a.Downgrade()
+
+	var wg sync.WaitGroup
+	for range 8 {
+		clone := a.Clone()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer clone.Release()
+
+			for range 1000 {
+				c := clone.Clone()
+				if up, ok := w.Upgrade(); ok {
+					up.Release()
+				}
+				c.Release()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(1, a.StrongCount())
+	assert.Equal(0, destroyed)
+
+	a.Release()
+	assert.Equal(1, destroyed)
+
+	_, ok := w.Upgrade()
+	assert.False(ok)
+	w.Release()
+}
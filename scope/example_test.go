@@ -0,0 +1,43 @@
+package scope_test
+
+import (
+	"fmt"
+
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/hashmap"
+	"github.com/joetifa2003/mm-go/scope"
+	"github.com/joetifa2003/mm-go/vector"
+)
+
+func buildIndex(s *scope.Scope) *hashmap.Hashmap[int, int] {
+	tmp := s.Child()
+	defer tmp.Close() // frees everything allocated bellow except the escaped index
+
+	squares := scope.Track(tmp, vector.New[int](tmp.Allocator()))
+	for i := range 5 {
+		squares.Push(i * i)
+	}
+
+	index := scope.Track(tmp, hashmap.New[int, int](tmp.Allocator()))
+	for i, sq := range squares.Iter() {
+		index.Set(sq, i)
+	}
+	tmp.Escape(index) // index is now owned by s
+
+	return index
+}
+
+func Example() {
+	alloc := allocator.NewC()
+	defer alloc.Destroy()
+
+	s := scope.New(alloc)
+	defer s.Close() // frees the index
+
+	index := buildIndex(s)
+
+	fmt.Println(index.Get(16))
+
+	// Output:
+	// 4 true
+}
@@ -0,0 +1,237 @@
+// scope provides lifetimes for manually managed memory.
+// Every container and raw allocation tracked by a Scope is freed in reverse order when the scope is closed,
+// instead of pairing every allocation with a defer.
+// Memory allocated through Scope.Allocator is recorded too, and whatever is left of it is freed when the root scope is closed.
+// Scopes can be nested, and objects can escape to the parent scope to outlive the child scope.
+package scope
+
+import (
+	"unsafe"
+
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/hashmap"
+)
+
+// Freer is implemented by all containers in mm-go (vector, hashmap, linkedlist, etc...)
+type Freer interface {
+	Free()
+}
+
+type entry struct {
+	key  any // Used to find the entry when escaping
+	free func()
+}
+
+// Scope tracks objects and frees them when closed.
+// Scope itself is managed by the go GC, it's not safe to store it in manually managed memory.
+type Scope struct {
+	alloc    allocator.Allocator
+	tracking allocator.Allocator // Records allocations in blocks, shared by the root scope and all its children
+	parent   *Scope
+	entries  []entry
+	children []*Scope
+	closed   bool
+}
+
+// blocks records the memory allocated through Scope.Allocator that is not freed yet,
+// it's the state of the tracking allocator so it's allocated using alloc like the containers that store it.
+type blocks struct {
+	alloc allocator.Allocator
+	live  *hashmap.Hashmap[block, struct{}] `mm:"manual"`
+}
+
+// block is a pointer allocated through the tracking allocator
+type block struct {
+	ptr unsafe.Pointer `mm:"manual"`
+}
+
+// New creates a new root scope, all objects are allocated using alloc
+func New(alloc allocator.Allocator) *Scope {
+	b := allocator.Alloc[blocks](alloc)
+	b.alloc = alloc
+	b.live = hashmap.New[block, struct{}](alloc)
+
+	tracking := allocator.NewAllocator(
+		unsafe.Pointer(b),
+		blocksAlloc,
+		blocksFree,
+		blocksRealloc,
+		blocksDestroy,
+	)
+
+	return &Scope{alloc: alloc, tracking: tracking}
+}
+
+// Allocator returns an allocator that records every allocation made through it,
+// memory that is still allocated when the root scope is closed is freed then,
+// so containers created with a child scope allocator stay valid when they escape.
+// Register containers using Track to free them when this scope is closed instead.
+// CAUTION: the allocator must not be used after the root scope is closed
+func (s *Scope) Allocator() allocator.Allocator {
+	root := s
+	for root.parent != nil {
+		root = root.parent
+	}
+	root.checkOpen()
+
+	return s.tracking
+}
+
+// Child creates a nested scope using the same allocator,
+// the child scope is closed automatically when the parent is closed if it's not closed already.
+func (s *Scope) Child() *Scope {
+	s.checkOpen()
+
+	child := &Scope{alloc: s.alloc, tracking: s.tracking, parent: s}
+	s.children = append(s.children, child)
+	return child
+}
+
+// Track registers obj to be freed when the scope is closed and returns it.
+//
+//	v := scope.Track(s, vector.New[int](s.Allocator()))
+func Track[F Freer](s *Scope, obj F) F {
+	s.checkOpen()
+
+	s.entries = append(s.entries, entry{key: obj, free: obj.Free})
+	return obj
+}
+
+// Alloc allocates T using the scope allocator, and frees it when the scope is closed.
+func Alloc[T any](s *Scope) *T {
+	s.checkOpen()
+
+	ptr := allocator.Alloc[T](s.alloc)
+	s.entries = append(s.entries, entry{
+		key:  ptr,
+		free: func() { allocator.Free(s.alloc, ptr) },
+	})
+	return ptr
+}
+
+// AllocMany allocates n of T using the scope allocator, and frees it when the scope is closed.
+// CAUTION: don't append to the slice, the purpose of it is to replace pointer
+// arithmetic with slice indexing
+func AllocMany[T any](s *Scope, n int) []T {
+	s.checkOpen()
+
+	slice := allocator.AllocMany[T](s.alloc, n)
+	s.entries = append(s.entries, entry{
+		key:  unsafe.SliceData(slice),
+		free: func() { allocator.FreeMany(s.alloc, slice) },
+	})
+	return slice
+}
+
+// Escape moves obj from the scope to the parent scope, so it outlives the scope.
+// obj must be tracked by the scope, either a container registered with Track or a pointer returned by Alloc.
+func (s *Scope) Escape(obj any) {
+	s.checkOpen()
+
+	if s.parent == nil {
+		panic("cannot escape from a root scope")
+	}
+	s.parent.checkOpen()
+
+	for i, e := range s.entries {
+		if e.key == obj {
+			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+			s.parent.entries = append(s.parent.entries, e)
+			return
+		}
+	}
+
+	panic("cannot escape an object that is not tracked by the scope")
+}
+
+// EscapeMany moves a slice returned by AllocMany to the parent scope, so it outlives the scope.
+func EscapeMany[T any](s *Scope, slice []T) {
+	s.Escape(unsafe.SliceData(slice))
+}
+
+// Close frees all objects tracked by the scope in reverse order, after closing all child scopes.
+// Calling Close more than once does nothing.
+func (s *Scope) Close() {
+	if s.closed {
+		return
+	}
+
+	for i := len(s.children) - 1; i >= 0; i-- {
+		s.children[i].Close()
+	}
+
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		s.entries[i].free()
+	}
+
+	s.entries = nil
+	s.children = nil
+	s.closed = true
+
+	if s.parent == nil {
+		s.tracking.Destroy()
+		return
+	}
+
+	for i, child := range s.parent.children {
+		if child == s {
+			s.parent.children = append(s.parent.children[:i], s.parent.children[i+1:]...)
+			break
+		}
+	}
+}
+
+func (s *Scope) checkOpen() {
+	if s.closed {
+		panic("cannot use a closed scope")
+	}
+}
+
+func blocksAlloc(a unsafe.Pointer, size int) unsafe.Pointer {
+	b := (*blocks)(a)
+
+	ptr := b.alloc.Alloc(size)
+	b.live.Set(block{ptr}, struct{}{})
+	return ptr
+}
+
+func blocksFree(a unsafe.Pointer, ptr unsafe.Pointer) {
+	b := (*blocks)(a)
+	if ptr == nil {
+		return
+	}
+
+	if _, ok := b.live.Get(block{ptr}); !ok {
+		panic("cannot free memory that is not allocated by the scope allocator (double free?)")
+	}
+	b.live.Delete(block{ptr})
+	b.alloc.Free(ptr)
+}
+
+func blocksRealloc(a unsafe.Pointer, ptr unsafe.Pointer, size int) unsafe.Pointer {
+	b := (*blocks)(a)
+	if ptr == nil {
+		return blocksAlloc(a, size)
+	}
+
+	if _, ok := b.live.Get(block{ptr}); !ok {
+		panic("cannot realloc memory that is not allocated by the scope allocator (use after free?)")
+	}
+	b.live.Delete(block{ptr})
+
+	newPtr := b.alloc.Realloc(ptr, size)
+	b.live.Set(block{newPtr}, struct{}{})
+	return newPtr
+}
+
+// blocksDestroy frees all the memory that is still allocated and the blocks, called when the root scope is closed
+func blocksDestroy(a unsafe.Pointer) {
+	b := (*blocks)(a)
+	alloc := b.alloc
+
+	for blk := range b.live.Iter() {
+		alloc.Free(blk.ptr)
+	}
+	b.live.Free()
+	allocator.Free(alloc, b)
+}
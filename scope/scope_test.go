@@ -0,0 +1,120 @@
+package scope_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/joetifa2003/mm-go/mmtest"
+	"github.com/joetifa2003/mm-go/scope"
+	"github.com/joetifa2003/mm-go/vector"
+)
+
+type freeRecorder struct {
+	name  string
+	freed *[]string
+}
+
+func (f *freeRecorder) Free() {
+	*f.freed = append(*f.freed, f.name)
+}
+
+func TestScopeCloseOrder(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	freed := []string{}
+	s := scope.New(alloc)
+	scope.Track(s, &freeRecorder{"a", &freed})
+	child := s.Child()
+	scope.Track(child, &freeRecorder{"child", &freed})
+	scope.Track(s, &freeRecorder{"b", &freed})
+
+	s.Close()
+	s.Close() // closing twice does nothing
+
+	assert.Equal([]string{"child", "b", "a"}, freed)
+	assert.Panics(func() {
+		scope.Alloc[int](s)
+	})
+}
+
+func TestScopeEscape(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	freed := []string{}
+	parent := scope.New(alloc)
+	child := parent.Child()
+
+	escaped := scope.Track(child, &freeRecorder{"escaped", &freed})
+	scope.Track(child, &freeRecorder{"local", &freed})
+	child.Escape(escaped)
+
+	child.Close()
+	assert.Equal([]string{"local"}, freed)
+
+	parent.Close()
+	assert.Equal([]string{"local", "escaped"}, freed)
+
+	root := scope.New(alloc)
+	defer root.Close()
+	assert.Panics(func() {
+		root.Escape(scope.Alloc[int](root))
+	})
+	assert.Panics(func() {
+		root.Child().Escape(&freeRecorder{})
+	})
+}
+
+func TestScopeContainers(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	s := scope.New(alloc)
+	defer s.Close()
+
+	child := s.Child()
+
+	v := scope.Track(child, vector.New[int](child.Allocator()))
+	v.Push(1)
+	v.Push(2)
+	child.Escape(v)
+
+	ptr := scope.Alloc[int](child)
+	*ptr = 15
+
+	ints := scope.AllocMany[int](child, 3)
+	ints[2] = 3
+	scope.EscapeMany(child, ints)
+
+	child.Close()
+
+	assert.Equal([]int{1, 2}, v.Slice())
+	assert.Equal(3, ints[2])
+}
+
+func TestScopeAllocator(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	s := scope.New(alloc)
+	child := s.Child()
+
+	// not tracked, freed when the root scope is closed
+	v := vector.New[int](child.Allocator())
+	for i := range 100 {
+		v.Push(i)
+	}
+	tracked := scope.Track(child, vector.New[int](child.Allocator()))
+	tracked.Push(1)
+
+	child.Close()
+	assert.Equal(99, v.Last()) // still valid until the root scope is closed
+	v.Push(100)
+
+	s.Close()
+	assert.Panics(func() {
+		vector.New[int](s.Allocator())
+	})
+}
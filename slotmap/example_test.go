@@ -0,0 +1,37 @@
+package slotmap_test
+
+import (
+	"fmt"
+
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/slotmap"
+)
+
+type Entity struct {
+	X, Y float32
+}
+
+func Example() {
+	alloc := allocator.NewC()
+	defer alloc.Destroy()
+
+	entities := slotmap.New[Entity](alloc)
+	defer entities.Free()
+
+	player := entities.Insert(Entity{X: 1, Y: 2})
+	enemy := entities.Insert(Entity{X: 3, Y: 4})
+
+	entities.Remove(enemy)
+
+	if e, ok := entities.Get(player); ok {
+		fmt.Println(*e)
+	}
+
+	if _, ok := entities.Get(enemy); !ok {
+		fmt.Println("enemy was removed")
+	}
+
+	// Output:
+	// {1 2}
+	// enemy was removed
+}
@@ -0,0 +1,187 @@
+// slotmap is a generational arena, values are stored in a dense manually managed array and accessed using handles.
+// A handle is an index and a generation, when a value is removed the generation of its slot is bumped,
+// so stale handles are detected instead of becoming dangling pointers.
+package slotmap
+
+import (
+	"iter"
+
+	"github.com/joetifa2003/mm-go"
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/vector"
+)
+
+// Handle is a reference to a value inside a SlotMap.
+// The zero value of Handle is never valid.
+type Handle[T any] struct {
+	index      uint32
+	generation uint32
+}
+
+type slot struct {
+	generation uint32
+	index      uint32 // Index of the value in the dense array if occupied, otherwise the next free slot
+	occupied   bool
+}
+
+// SlotMap a dense generational arena
+type SlotMap[T any] struct {
+	slots    *vector.Vector[slot]
+	values   *vector.Vector[T]
+	valueIdx *vector.Vector[uint32] // Slot index of each value in the dense array
+	freeHead uint32                 // Index of the first free slot + 1, zero if there is no free slots
+	alloc    allocator.Allocator
+}
+
+// New creates a new empty SlotMap
+func New[T any](alloc allocator.Allocator) *SlotMap[T] {
+	sm := allocator.Alloc[SlotMap[T]](alloc)
+	sm.slots = vector.New[slot](alloc)
+	sm.values = vector.New[T](alloc)
+	sm.valueIdx = vector.New[uint32](alloc)
+	sm.alloc = alloc
+	return sm
+}
+
+// Insert inserts value T and returns a handle to it
+func (sm *SlotMap[T]) Insert(value T) Handle[T] {
+	var slotIdx uint32
+	if sm.freeHead != 0 {
+		slotIdx = sm.freeHead - 1
+		s := sm.slots.AtPtr(int(slotIdx))
+		sm.freeHead = s.index
+	} else {
+		slotIdx = uint32(sm.slots.Len())
+		sm.slots.Push(slot{generation: 1})
+	}
+
+	s := sm.slots.AtPtr(int(slotIdx))
+	s.index = uint32(sm.values.Len())
+	s.occupied = true
+
+	sm.values.Push(value)
+	sm.valueIdx.Push(slotIdx)
+
+	return Handle[T]{index: slotIdx, generation: s.generation}
+}
+
+func (sm *SlotMap[T]) lookup(h Handle[T]) (*slot, bool) {
+	if int(h.index) >= sm.slots.Len() {
+		return nil, false
+	}
+
+	s := sm.slots.AtPtr(int(h.index))
+	if !s.occupied || s.generation != h.generation {
+		return nil, false
+	}
+
+	return s, true
+}
+
+// Get returns a pointer to the value referenced by the handle,
+// returns false if the handle is stale (the value was removed).
+// CAUTION: the pointer is invalidated by the next Insert or Remove, use the handle to keep a reference
+func (sm *SlotMap[T]) Get(h Handle[T]) (*T, bool) {
+	s, ok := sm.lookup(h)
+	if !ok {
+		return nil, false
+	}
+
+	return sm.values.AtPtr(int(s.index)), true
+}
+
+// Contains checks if the handle still references a value in the SlotMap
+func (sm *SlotMap[T]) Contains(h Handle[T]) bool {
+	_, ok := sm.lookup(h)
+	return ok
+}
+
+// Remove removes the value referenced by the handle and returns it,
+// all handles to this value become stale.
+// returns false if the handle is already stale
+func (sm *SlotMap[T]) Remove(h Handle[T]) (T, bool) {
+	s, ok := sm.lookup(h)
+	if !ok {
+		return mm.Zero[T](), false
+	}
+
+	valueIdx := int(s.index)
+	lastIdx := sm.values.Len() - 1
+
+	// the last value is moved into the hole, so its slot has to point to the new location
+	if valueIdx != lastIdx {
+		movedSlot := sm.valueIdx.At(lastIdx)
+		sm.slots.AtPtr(int(movedSlot)).index = uint32(valueIdx)
+	}
+
+	value := sm.values.RemoveAt(valueIdx)
+	sm.valueIdx.RemoveAt(valueIdx)
+
+	s.occupied = false
+	s.generation++
+	s.index = sm.freeHead
+	sm.freeHead = h.index + 1
+
+	return value, true
+}
+
+// Len returns the number of values in the SlotMap
+func (sm *SlotMap[T]) Len() int {
+	return sm.values.Len()
+}
+
+// Values returns the dense array of values as a slice
+// CAUTION: don't append to this slice, this is only used
+// if you want to loop on the values
+func (sm *SlotMap[T]) Values() []T {
+	return sm.values.Slice()
+}
+
+// Iter returns an iterator over all handles and values
+func (sm *SlotMap[T]) Iter() iter.Seq2[Handle[T], T] {
+	return func(yield func(Handle[T], T) bool) {
+		for i, value := range sm.values.Iter() {
+			slotIdx := sm.valueIdx.UnsafeAt(i)
+			h := Handle[T]{index: slotIdx, generation: sm.slots.UnsafeAt(int(slotIdx)).generation}
+			if !yield(h, value) {
+				return
+			}
+		}
+	}
+}
+
+// Clone returns a copy of the SlotMap using the same allocator,
+// handles to the original SlotMap are valid for the copy.
+// Values are copied shallowly, pass cloneElem to deep copy them.
+func (sm *SlotMap[T]) Clone(cloneElem ...func(T) T) *SlotMap[T] {
+	return sm.CloneInto(sm.alloc, cloneElem...)
+}
+
+// CloneInto returns a copy of the SlotMap allocated using alloc,
+// handles to the original SlotMap are valid for the copy.
+// Values are copied shallowly, pass cloneElem to deep copy them.
+func (sm *SlotMap[T]) CloneInto(alloc allocator.Allocator, cloneElem ...func(T) T) *SlotMap[T] {
+	clone := allocator.Alloc[SlotMap[T]](alloc)
+	clone.slots = sm.slots.CloneInto(alloc)
+	clone.values = sm.values.CloneInto(alloc, cloneElem...)
+	clone.valueIdx = sm.valueIdx.CloneInto(alloc)
+	clone.freeHead = sm.freeHead
+	clone.alloc = alloc
+	return clone
+}
+
+// MoveTo copies the SlotMap into alloc and frees the original,
+// the values are moved as is and the original SlotMap must not be used afterwards.
+func (sm *SlotMap[T]) MoveTo(alloc allocator.Allocator) *SlotMap[T] {
+	moved := sm.CloneInto(alloc)
+	sm.Free()
+	return moved
+}
+
+// Free frees the SlotMap
+func (sm *SlotMap[T]) Free() {
+	sm.slots.Free()
+	sm.values.Free()
+	sm.valueIdx.Free()
+	allocator.Free(sm.alloc, sm)
+}
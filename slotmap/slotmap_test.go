@@ -0,0 +1,115 @@
+package slotmap_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/joetifa2003/mm-go/mmtest"
+	"github.com/joetifa2003/mm-go/slotmap"
+)
+
+func TestSlotMap(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	sm := slotmap.New[int](alloc)
+	defer sm.Free()
+
+	h1 := sm.Insert(1)
+	h2 := sm.Insert(2)
+	h3 := sm.Insert(3)
+	assert.Equal(3, sm.Len())
+
+	v, ok := sm.Get(h2)
+	assert.True(ok)
+	assert.Equal(2, *v)
+
+	removed, ok := sm.Remove(h1)
+	assert.True(ok)
+	assert.Equal(1, removed)
+	assert.Equal(2, sm.Len())
+
+	// stale handle
+	_, ok = sm.Get(h1)
+	assert.False(ok)
+	_, ok = sm.Remove(h1)
+	assert.False(ok)
+	assert.False(sm.Contains(h1))
+
+	// the last value was moved into the removed value place
+	v, ok = sm.Get(h3)
+	assert.True(ok)
+	assert.Equal(3, *v)
+
+	// the slot is reused with a new generation
+	h4 := sm.Insert(4)
+	assert.NotEqual(h1, h4)
+	_, ok = sm.Get(h1)
+	assert.False(ok)
+	v, ok = sm.Get(h4)
+	assert.True(ok)
+	assert.Equal(4, *v)
+
+	assert.ElementsMatch([]int{2, 3, 4}, sm.Values())
+
+	var zero slotmap.Handle[int]
+	assert.False(sm.Contains(zero))
+}
+
+func TestSlotMapClone(t *testing.T) {
+	scratch := mmtest.NewAllocator(t)
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	sm := slotmap.New[int](scratch)
+	h1 := sm.Insert(1)
+	h2 := sm.Insert(2)
+	sm.Remove(h1)
+
+	clone := sm.Clone(func(i int) int { return i * 10 })
+	defer clone.Free()
+
+	moved := sm.MoveTo(alloc)
+	defer moved.Free()
+
+	// handles are valid for the copies
+	v, ok := moved.Get(h2)
+	assert.True(ok)
+	assert.Equal(2, *v)
+	assert.False(moved.Contains(h1))
+
+	v, ok = clone.Get(h2)
+	assert.True(ok)
+	assert.Equal(20, *v)
+
+	// the free list is preserved
+	h3 := moved.Insert(3)
+	assert.True(moved.Contains(h3))
+	assert.False(moved.Contains(h1))
+}
+
+func TestSlotMapIter(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	sm := slotmap.New[int](alloc)
+	defer sm.Free()
+
+	handles := []slotmap.Handle[int]{}
+	for i := range 10 {
+		handles = append(handles, sm.Insert(i))
+	}
+	for i := 0; i < 10; i += 2 {
+		sm.Remove(handles[i])
+	}
+
+	sum := 0
+	for h, v := range sm.Iter() {
+		got, ok := sm.Get(h)
+		assert.True(ok)
+		assert.Equal(v, *got)
+		sum += v
+	}
+	assert.Equal(1+3+5+7+9, sum)
+}
@@ -33,7 +33,7 @@ func Example() {
 		fmt.Println(e.VelocityX, e.VelocityY, e.PositionX, e.PositionY)
 	}
 
-	entities := arena.AllocMany(10) // allocate slice of 10 entities (cannot exceed 10 here because chunk size is 10 above, this limitation doesn't exist in batchallocator)
+	entities := arena.AllocMany(10) // allocate slice of 10 entities (if it exceeds the chunk size a dedicated chunk is allocated)
 
 	_ = entities
 
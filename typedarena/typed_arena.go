@@ -2,13 +2,20 @@
 package typedarena
 
 import (
+	"iter"
+	"sort"
+	"unsafe"
+
+	"github.com/joetifa2003/mm-go"
 	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/bitset"
 	"github.com/joetifa2003/mm-go/vector"
 )
 
 type typedChunk[T any] struct {
-	data  []T
+	data  []T `mm:"manual"`
 	len   int
+	freed *bitset.BitSet // Slots freed by FreeObject, allocated on the first free in the chunk
 	alloc allocator.Allocator
 }
 
@@ -31,16 +38,63 @@ func (c *typedChunk[T]) AllocMany(n int) []T {
 	return c.data[oldLen:c.len]
 }
 
+// base returns the address of the chunk data
+func (c *typedChunk[T]) base() uintptr {
+	return uintptr(unsafe.Pointer(unsafe.SliceData(c.data)))
+}
+
+// index returns the index of ptr in the chunk
+func (c *typedChunk[T]) index(ptr *T) (int, bool) {
+	base := c.base()
+	offset := uintptr(unsafe.Pointer(ptr)) - base
+	size := unsafe.Sizeof(*ptr)
+	if uintptr(unsafe.Pointer(ptr)) < base || offset >= uintptr(len(c.data))*size {
+		return 0, false
+	}
+
+	return int(offset / size), true
+}
+
+func (c *typedChunk[T]) isFreed(i int) bool {
+	return c.freed != nil && c.freed.Test(i)
+}
+
 func (c *typedChunk[T]) Free() {
+	if c.freed != nil {
+		c.freed.Free()
+	}
 	allocator.FreeMany(c.alloc, c.data)
 	allocator.Free(c.alloc, c)
 }
 
-// TypedArena is a growable typed arena
+// TypedArena is a growable typed arena.
+// Unlike the other containers it has no Clone, CloneInto or MoveTo, its objects are used through the pointers returned by Alloc,
+// and a copy would move them to new addresses, leaving those pointers pointing into the original arena.
 type TypedArena[T any] struct {
 	chunks    *vector.Vector[*typedChunk[T]]
+	byAddress *vector.Vector[*typedChunk[T]] // The chunks sorted by the address of their data, to find the chunk of a pointer
+	current   int                            // Index of the chunk currently used for allocations
+	freeList  unsafe.Pointer                 `mm:"manual"` // Intrusive list of objects freed by FreeObject
 	chunkSize int
 	alloc     allocator.Allocator
+
+	growthFactor float64
+	destructor   func(*T) `mm:"static"`
+}
+
+type typedArenaOptions struct {
+	growthFactor float64
+}
+
+type TypedArenaOption func(opts *typedArenaOptions)
+
+// WithGrowthFactor Option to grow the chunk size geometrically,
+// every new chunk will be factor times bigger than the previous one.
+// factor must be greater than 1 to take effect.
+func WithGrowthFactor(factor float64) TypedArenaOption {
+	return func(opts *typedArenaOptions) {
+		opts.growthFactor = factor
+	}
 }
 
 // New creates a typed arena with the specified chunk size.
@@ -48,53 +102,217 @@ type TypedArena[T any] struct {
 // chunk size is 5, then each chunk is going to hold 5 ints. And if the
 // chunk is filled it will allocate another chunk that can hold 5 ints.
 // then you can call FreeArena and it will deallocate all chunks together
-func New[T any](alloc allocator.Allocator, chunkSize int) *TypedArena[T] {
+func New[T any](alloc allocator.Allocator, chunkSize int, options ...TypedArenaOption) *TypedArena[T] {
+	return NewWithDestructor[T](alloc, chunkSize, nil, options...)
+}
+
+// NewWithDestructor creates a typed arena like New, destructor is called on every live object
+// when the arena is freed or reset, or when the object is freed using FreeObject.
+// This is useful when T owns other manually managed resources (like a vector).
+// destructor is stored in manually managed memory, see [mm] for the functions that can be stored.
+func NewWithDestructor[T any](alloc allocator.Allocator, chunkSize int, destructor func(*T), options ...TypedArenaOption) *TypedArena[T] {
+	var opts typedArenaOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
 	tArena := allocator.Alloc[TypedArena[T]](alloc)
 	tArena.chunkSize = chunkSize
 	tArena.chunks = vector.New[*typedChunk[T]](alloc)
+	tArena.byAddress = vector.New[*typedChunk[T]](alloc)
 	tArena.alloc = alloc
+	tArena.growthFactor = opts.growthFactor
+	tArena.destructor = destructor
 
-	firstChunk := newChunk[T](alloc, chunkSize)
-	tArena.chunks.Push(firstChunk)
+	tArena.chunks.Push(tArena.allocChunk(chunkSize))
 
 	return tArena
 }
 
-// Alloc allocates T from the arena
+// Alloc allocates T from the arena, reusing objects freed by FreeObject first
 func (ta *TypedArena[T]) Alloc() *T {
-	lastChunk := ta.chunks.Last()
-	if lastChunk.len == ta.chunkSize {
-		nc := newChunk[T](ta.alloc, ta.chunkSize)
-		ta.chunks.Push(nc)
-		return nc.Alloc()
+	if ta.freeList != nil {
+		ptr := (*T)(ta.freeList)
+		ta.freeList = *(*unsafe.Pointer)(ta.freeList)
+		c, i := ta.chunkOf(ptr)
+		c.freed.Clear(i)
+		*ptr = mm.Zero[T]()
+		return ptr
 	}
-	return lastChunk.Alloc()
+
+	return ta.chunkFor(1).Alloc()
 }
 
 // AllocMany allocates n of T and returns a slice representing the heap.
 // CAUTION: don't append to the slice, the purpose of it is to replace pointer
 // arithmetic with slice indexing
-// CAUTION: n cannot exceed chunk size
+// If n exceeds the chunk size, a dedicated chunk of exactly n elements is allocated.
 func (ta *TypedArena[T]) AllocMany(n int) []T {
 	if n > ta.chunkSize {
-		panic("cannot exceed chunk size")
+		return ta.allocDedicated(n).AllocMany(n)
+	}
+
+	return ta.chunkFor(n).AllocMany(n)
+}
+
+// FreeObject returns an object allocated by Alloc to the arena,
+// it will be reused by the next call to Alloc.
+// The freed object memory is used to store the free list, so T must be at least the size of a pointer.
+// CAUTION: don't free objects allocated by AllocMany
+func (ta *TypedArena[T]) FreeObject(ptr *T) {
+	if mm.SizeOf[T]() < mm.SizeOf[unsafe.Pointer]() {
+		panic("cannot free an object smaller than a pointer")
+	}
+
+	c, i := ta.chunkOf(ptr)
+	if c == nil || i >= c.len {
+		panic("cannot free an object that is not allocated by the arena")
+	}
+	if c.isFreed(i) {
+		panic("cannot free an object twice")
+	}
+
+	if ta.destructor != nil {
+		ta.destructor(ptr)
+	}
+
+	if c.freed == nil {
+		c.freed = bitset.New(ta.alloc)
+	}
+	c.freed.Set(i)
+
+	*(*unsafe.Pointer)(unsafe.Pointer(ptr)) = ta.freeList
+	ta.freeList = unsafe.Pointer(ptr)
+}
+
+// Reset rewinds the arena so all of its memory can be reused,
+// chunks are retained and not returned to the allocator.
+// CAUTION: all pointers previously allocated from the arena become invalid
+func (ta *TypedArena[T]) Reset() {
+	ta.destroyAll()
+
+	for _, c := range ta.chunks.Slice() {
+		clear(c.data[:c.len])
+		c.len = 0
+		if c.freed != nil {
+			c.freed.Reset()
+		}
+	}
+	ta.current = 0
+	ta.freeList = nil
+}
+
+// allocChunk allocates a chunk of size elements and adds it to byAddress,
+// the caller adds it to chunks
+func (ta *TypedArena[T]) allocChunk(size int) *typedChunk[T] {
+	nc := newChunk[T](ta.alloc, size)
+
+	chunks := ta.byAddress.Slice()
+	i := sort.Search(len(chunks), func(i int) bool { return chunks[i].base() > nc.base() })
+	ta.byAddress.Insert(i, nc)
+
+	return nc
+}
+
+// chunkOf returns the chunk that contains ptr and its index in the chunk, or nil if no chunk contains it,
+// it's the last chunk that starts at or before ptr.
+func (ta *TypedArena[T]) chunkOf(ptr *T) (*typedChunk[T], int) {
+	addr := uintptr(unsafe.Pointer(ptr))
+	chunks := ta.byAddress.Slice()
+	n := sort.Search(len(chunks), func(i int) bool { return chunks[i].base() > addr })
+	if n == 0 {
+		return nil, 0
 	}
 
-	lastChunk := ta.chunks.Last()
-	if lastChunk.len+n > ta.chunkSize {
-		nc := newChunk[T](ta.alloc, ta.chunkSize)
-		ta.chunks.Push(nc)
-		return nc.AllocMany(n)
+	c := chunks[n-1]
+	if i, ok := c.index(ptr); ok {
+		return c, i
 	}
 
-	return lastChunk.AllocMany(n)
+	return nil, 0
+}
+
+// chunkFor returns a chunk that can fit n elements,
+// moving to the next retained chunk or allocating a new one if needed
+func (ta *TypedArena[T]) chunkFor(n int) *typedChunk[T] {
+	for {
+		c := ta.chunks.At(ta.current)
+		if c.len+n <= len(c.data) {
+			return c
+		}
+
+		if ta.current == ta.chunks.Len()-1 {
+			return ta.grow()
+		}
+
+		ta.current++
+	}
+}
+
+// grow allocates a new chunk, and grows the chunk size if a growth factor is configured
+func (ta *TypedArena[T]) grow() *typedChunk[T] {
+	if ta.growthFactor > 1 {
+		ta.chunkSize = max(ta.chunkSize+1, int(float64(ta.chunkSize)*ta.growthFactor))
+	}
+
+	nc := ta.allocChunk(ta.chunkSize)
+	ta.chunks.Push(nc)
+	ta.current = ta.chunks.Len() - 1
+	return nc
+}
+
+// allocDedicated allocates a chunk of exactly n elements,
+// and puts it before the current chunk so the current chunk is still used for next allocations
+func (ta *TypedArena[T]) allocDedicated(n int) *typedChunk[T] {
+	nc := ta.allocChunk(n)
+	ta.chunks.Push(nc)
+
+	for i := ta.chunks.Len() - 1; i > ta.current; i-- {
+		ta.chunks.Set(i, ta.chunks.At(i-1))
+	}
+	ta.chunks.Set(ta.current, nc)
+	ta.current++
+
+	return nc
+}
+
+// All returns an iterator over all live objects in the arena,
+// objects freed by FreeObject are skipped.
+func (ta *TypedArena[T]) All() iter.Seq[*T] {
+	return func(yield func(*T) bool) {
+		for _, c := range ta.chunks.Iter() {
+			for i := range c.len {
+				if c.isFreed(i) {
+					continue
+				}
+
+				if !yield(&c.data[i]) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// destroyAll calls the destructor on all live objects
+func (ta *TypedArena[T]) destroyAll() {
+	if ta.destructor == nil {
+		return
+	}
+
+	for ptr := range ta.All() {
+		ta.destructor(ptr)
+	}
 }
 
 // Free frees all allocated memory
 func (ta *TypedArena[T]) Free() {
+	ta.destroyAll()
+
 	for _, c := range ta.chunks.Slice() {
 		c.Free()
 	}
 	ta.chunks.Free()
+	ta.byAddress.Free()
 	allocator.Free(ta.alloc, ta)
 }
@@ -2,15 +2,18 @@ package typedarena_test
 
 import (
 	"testing"
+	"unsafe"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/mmtest"
 	"github.com/joetifa2003/mm-go/typedarena"
+	"github.com/joetifa2003/mm-go/vector"
 )
 
 func TestTypedArena(t *testing.T) {
-	alloc := allocator.NewC()
+	alloc := mmtest.NewAllocator(t)
 	assert := assert.New(t)
 
 	arena := typedarena.New[int](alloc, 4)
@@ -26,9 +29,8 @@ func TestTypedArena(t *testing.T) {
 	intPtr1 := &ints[0]
 	*intPtr1 = 15
 
-	assert.Panics(func() {
-		arena.AllocMany(10) // bigger than chunk size
-	})
+	big := arena.AllocMany(10) // bigger than chunk size, allocates a dedicated chunk
+	assert.Equal(10, len(big))
 
 	arena.AllocMany(4) // creates a new chunk to fit in new values
 
@@ -39,3 +41,256 @@ func TestTypedArena(t *testing.T) {
 	assert.Equal(15, ints[0])
 	assert.Equal(3, ints[1])
 }
+
+func TestTypedArenaDedicatedChunk(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	arena := typedarena.New[int](alloc, 4)
+	defer arena.Free()
+
+	first := arena.Alloc()
+	*first = 1
+
+	big := arena.AllocMany(100)
+	for i := range big {
+		big[i] = i
+	}
+
+	// the dedicated chunk doesn't take the place of the current chunk
+	second := arena.Alloc()
+	*second = 2
+	assert.Equal(unsafe.Sizeof(int(0)), uintptr(unsafe.Pointer(second))-uintptr(unsafe.Pointer(first)))
+
+	assert.Equal(1, *first)
+	assert.Equal(2, *second)
+	assert.Equal(99, big[99])
+}
+
+func TestTypedArenaGrowthFactor(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	arena := typedarena.New[int](alloc, 2, typedarena.WithGrowthFactor(2))
+	defer arena.Free()
+
+	ptrs := make([]*int, 0, 14)
+	for i := range 14 { // 2 + 4 + 8
+		p := arena.Alloc()
+		*p = i
+		ptrs = append(ptrs, p)
+	}
+
+	// elements 6 to 13 live in the same chunk of 8
+	for i := 7; i < 14; i++ {
+		assert.Equal(unsafe.Sizeof(int(0)), uintptr(unsafe.Pointer(ptrs[i]))-uintptr(unsafe.Pointer(ptrs[i-1])))
+	}
+
+	for i, p := range ptrs {
+		assert.Equal(i, *p)
+	}
+}
+
+func TestTypedArenaReset(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	arena := typedarena.New[int](alloc, 2)
+	defer arena.Free()
+
+	first := arena.Alloc()
+	*first = 1
+	arena.AllocMany(2)
+	for range 3 {
+		*arena.Alloc() = 2
+	}
+
+	arena.Reset()
+
+	// memory is reused from the first chunk and zeroed
+	p := arena.Alloc()
+	assert.Same(first, p)
+	assert.Equal(0, *p)
+
+	// retained chunks are reused before allocating new ones
+	for range 20 {
+		assert.Equal(0, *arena.Alloc())
+	}
+}
+
+func TestTypedArenaFreeObject(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	arena := typedarena.New[[2]int](alloc, 4)
+	defer arena.Free()
+
+	a := arena.Alloc()
+	b := arena.Alloc()
+	a[0] = 1
+	b[0] = 2
+
+	arena.FreeObject(a)
+	arena.FreeObject(b)
+
+	// freed objects are reused in LIFO order and zeroed
+	assert.Same(b, arena.Alloc())
+	reused := arena.Alloc()
+	assert.Same(a, reused)
+	assert.Equal([2]int{}, *reused)
+
+	c := arena.Alloc()
+	assert.NotSame(a, c)
+	assert.NotSame(b, c)
+
+	small := typedarena.New[int32](alloc, 4)
+	defer small.Free()
+	assert.Panics(func() {
+		small.FreeObject(small.Alloc())
+	})
+}
+
+func TestTypedArenaAll(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	arena := typedarena.New[[2]int](alloc, 2)
+	defer arena.Free()
+
+	ptrs := make([]*[2]int, 0, 5)
+	for i := range 5 {
+		p := arena.Alloc()
+		p[0] = i
+		ptrs = append(ptrs, p)
+	}
+	arena.FreeObject(ptrs[1])
+	arena.FreeObject(ptrs[3])
+
+	values := []int{}
+	for p := range arena.All() {
+		values = append(values, p[0])
+	}
+	assert.Equal([]int{0, 2, 4}, values)
+
+	for range arena.All() {
+		break // stops early
+	}
+}
+
+type entity struct {
+	children *vector.Vector[int]
+}
+
+var destroyedEntities int
+
+func destroyEntity(e *entity) {
+	e.children.Free()
+	destroyedEntities++
+}
+
+func TestTypedArenaDestructor(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	destroyedEntities = 0
+	arena := typedarena.NewWithDestructor(alloc, 2, destroyEntity)
+
+	for range 3 {
+		e := arena.Alloc()
+		e.children = vector.New[int](alloc)
+		e.children.Push(1)
+	}
+
+	arena.Reset()
+	assert.Equal(3, destroyedEntities)
+
+	for range 2 {
+		e := arena.Alloc()
+		e.children = vector.New[int](alloc)
+	}
+	e := arena.Alloc()
+	e.children = vector.New[int](alloc)
+	arena.FreeObject(e) // destructor is called on FreeObject too
+	assert.Equal(4, destroyedEntities)
+
+	e = arena.Alloc() // reuses the freed object
+	e.children = vector.New[int](alloc)
+
+	arena.Free()
+	assert.Equal(7, destroyedEntities)
+}
+
+func TestTypedArenaFreeObjectInvalid(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	arena := typedarena.New[[2]int](alloc, 2)
+	defer arena.Free()
+
+	p := arena.Alloc()
+	arena.FreeObject(p)
+	assert.Panics(func() {
+		arena.FreeObject(p)
+	})
+
+	var outside [2]int
+	assert.Panics(func() {
+		arena.FreeObject(&outside)
+	})
+}
+
+func TestTypedArenaFreeObjectManyChunks(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	arena := typedarena.New[[2]int](alloc, 4)
+	defer arena.Free()
+
+	objects := make([]*[2]int, 0, 100)
+	for i := range 100 {
+		if i%10 == 0 {
+			arena.AllocMany(8) // dedicated chunks between the regular ones
+		}
+		objects = append(objects, arena.Alloc())
+	}
+
+	for i := range 50 {
+		arena.FreeObject(objects[i*2])
+	}
+	assert.Panics(func() {
+		arena.FreeObject(objects[0])
+	})
+
+	live := 0
+	for range arena.All() {
+		live++
+	}
+	assert.Equal(50+10*8, live)
+
+	for range 50 {
+		arena.Alloc()
+	}
+	live = 0
+	for range arena.All() {
+		live++
+	}
+	assert.Equal(100+10*8, live)
+}
+
+func BenchmarkTypedArenaFreeObject(b *testing.B) {
+	alloc := allocator.NewC()
+	defer alloc.Destroy()
+
+	arena := typedarena.New[[2]int](alloc, 64)
+	defer arena.Free()
+
+	for range 100_000 {
+		arena.Alloc()
+	}
+	ptr := arena.Alloc()
+
+	for range b.N {
+		arena.FreeObject(ptr)
+		ptr = arena.Alloc()
+	}
+}
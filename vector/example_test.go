@@ -32,3 +32,22 @@ func Example() {
 	// 2
 	// 3
 }
+
+func ExampleNewSmall() {
+	alloc := allocator.NewC()
+	defer alloc.Destroy()
+
+	v := vector.NewSmall[int, [2]int](alloc)
+	defer v.Free()
+
+	v.Push(1)
+	v.Push(2)
+	fmt.Println(v.Slice(), v.IsInline())
+
+	v.Push(3)
+	fmt.Println(v.Slice(), v.IsInline())
+
+	// Output:
+	// [1 2] true
+	// [1 2 3] false
+}
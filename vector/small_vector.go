@@ -0,0 +1,175 @@
+package vector
+
+import (
+	"fmt"
+	"iter"
+	"reflect"
+	"unsafe"
+
+	"github.com/joetifa2003/mm-go"
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/internal/debug"
+)
+
+// SmallVector is a Vector that stores up to N elements inline in its header,
+// and only allocates from the allocator when it grows beyond that.
+// A is the inline storage and must be an array of T, for example SmallVector[int, [8]int].
+// It has all the methods of a Vector, Clone, CloneInto and MoveTo return a regular Vector.
+// The embedded Vector doesn't know about the inline storage, so don't grow or free it directly.
+type SmallVector[T any, A any] struct {
+	Vector[T] // must be the first field, so freeing the vector frees the whole header
+	storage   A
+}
+
+// NewSmall creates a new empty SmallVector with the capacity of its inline storage,
+// and applies optional configuration using VectorOption.
+func NewSmall[T any, A any](alloc allocator.Allocator, options ...VectorOption) *SmallVector[T, A] {
+	n := inlineLen[T, A]()
+	mm.CheckGoPointers[T]()
+
+	sv := allocator.Alloc[SmallVector[T, A]](alloc)
+	sv.alloc = alloc
+	sv.data = unsafe.Slice((*T)(unsafe.Pointer(&sv.storage)), n)
+	for _, option := range options {
+		option(&sv.growth)
+	}
+	debug.Track(unsafe.Pointer(sv))
+
+	return sv
+}
+
+// IsInline checks if the elements are stored inline, which is the case until the vector grows beyond its inline storage.
+func (sv *SmallVector[T, A]) IsInline() bool {
+	sv.check()
+	return sv.isInline()
+}
+
+// Push pushes value T to the vector, grows if needed.
+func (sv *SmallVector[T, A]) Push(value T) {
+	sv.check()
+	sv.spill(sv.len + 1)
+	sv.Vector.Push(value)
+}
+
+// Insert inserts values at the specified index, shifting the elements after it to the right.
+// idx can be equal to the length of the vector to insert at the end.
+func (sv *SmallVector[T, A]) Insert(idx int, values ...T) {
+	sv.check()
+	sv.spill(sv.len + len(values)) // the inline storage stays valid, so values can point into it
+	sv.Vector.Insert(idx, values...)
+}
+
+// Extend appends the values to the vector, growing it at most once.
+func (sv *SmallVector[T, A]) Extend(values []T) {
+	sv.check()
+	sv.spill(sv.len + len(values))
+	sv.Vector.Extend(values)
+}
+
+// ExtendSeq appends the values yielded by seq to the vector,
+// the number of values is not known in advance so it can grow more than once, call Reserve first if you know it.
+func (sv *SmallVector[T, A]) ExtendSeq(seq iter.Seq[T]) {
+	for value := range seq {
+		sv.Push(value)
+	}
+}
+
+// Reserve makes sure the vector can hold at least n more elements without growing.
+func (sv *SmallVector[T, A]) Reserve(n int) {
+	sv.check()
+
+	if n < 0 {
+		panic(fmt.Sprintf("cannot reserve %d elements", n))
+	}
+
+	if sv.isInline() && sv.len+n > sv.Cap() {
+		sv.moveOut(sv.len + n)
+	}
+	sv.Vector.Reserve(n)
+}
+
+// Resize changes the length of the vector to n,
+// new elements are set to the zero value of T.
+func (sv *SmallVector[T, A]) Resize(n int) {
+	sv.check()
+	sv.spill(n)
+	sv.Vector.Resize(n)
+}
+
+// ShrinkToFit shrinks the capacity of the vector to its length,
+// the elements are moved back into the inline storage if they fit.
+func (sv *SmallVector[T, A]) ShrinkToFit() {
+	sv.check()
+
+	if sv.isInline() {
+		return
+	}
+
+	inline := sv.inline()
+	if sv.len > len(inline) {
+		sv.Vector.ShrinkToFit()
+		return
+	}
+
+	copy(inline, sv.data[:sv.len])
+	allocator.FreeMany(sv.alloc, sv.data)
+	sv.data = inline
+}
+
+// MoveTo copies the vector into alloc and frees the original,
+// the elements are moved as is and the original vector must not be used afterwards.
+func (sv *SmallVector[T, A]) MoveTo(alloc allocator.Allocator) *Vector[T] {
+	moved := sv.CloneInto(alloc)
+	sv.Free()
+	return moved
+}
+
+// Free deallocats the vector
+func (sv *SmallVector[T, A]) Free() {
+	if !sv.isInline() {
+		sv.Vector.Free()
+		return
+	}
+
+	debug.Untrack(unsafe.Pointer(sv), "vector")
+
+	alloc := sv.alloc
+	debug.Poison(unsafe.Pointer(sv), mm.SizeOf[SmallVector[T, A]]())
+
+	allocator.Free(alloc, sv)
+}
+
+// spill moves the elements out of the inline storage if it can't hold n elements,
+// after that the embedded Vector grows on its own.
+func (sv *SmallVector[T, A]) spill(n int) {
+	if n > sv.Cap() && sv.isInline() {
+		sv.moveOut(sv.growth.next(sv.Cap(), n))
+	}
+}
+
+// moveOut moves the elements from the inline storage to a new allocation of newCap elements
+func (sv *SmallVector[T, A]) moveOut(newCap int) {
+	data := allocator.AllocMany[T](sv.alloc, newCap)
+	copy(data, sv.data[:sv.len])
+	sv.data = data
+}
+
+// isInline checks if the elements are stored in the inline storage
+func (sv *SmallVector[T, A]) isInline() bool {
+	return unsafe.Pointer(unsafe.SliceData(sv.data)) == unsafe.Pointer(&sv.storage)
+}
+
+// inline returns the inline storage as a slice
+func (sv *SmallVector[T, A]) inline() []T {
+	return unsafe.Slice((*T)(unsafe.Pointer(&sv.storage)), inlineLen[T, A]())
+}
+
+// inlineLen returns the length of the inline storage A, panics if A is not a non empty array of T
+func inlineLen[T any, A any]() int {
+	storage := reflect.TypeFor[A]()
+	if storage.Kind() != reflect.Array || storage.Elem() != reflect.TypeFor[T]() || storage.Len() == 0 {
+		panic(fmt.Sprintf("cannot use %s as the inline storage of a small vector of %s", storage, reflect.TypeFor[T]()))
+	}
+
+	return storage.Len()
+}
@@ -0,0 +1,143 @@
+package vector_test
+
+import (
+	"slices"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/mmtest"
+	"github.com/joetifa2003/mm-go/vector"
+)
+
+func TestSmallVector(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	v := vector.NewSmall[int, [4]int](alloc)
+	defer v.Free()
+
+	assert.Equal(4, v.Cap())
+	assert.True(v.IsInline())
+
+	v.Extend([]int{1, 2, 3, 4})
+	assert.True(v.IsInline())
+
+	v.Insert(0, 0)
+	assert.False(v.IsInline())
+	assert.Equal(8, v.Cap())
+	assert.Equal([]int{0, 1, 2, 3, 4}, v.Slice())
+
+	v.Pop()
+	v.ShrinkToFit() // moves the elements back inline
+	assert.True(v.IsInline())
+	assert.Equal(4, v.Cap())
+	assert.Equal([]int{0, 1, 2, 3}, v.Slice())
+
+	moved := v.Clone()
+	defer moved.Free()
+	assert.Equal([]int{0, 1, 2, 3}, moved.Slice())
+}
+
+func TestSmallVectorGrow(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	v := vector.NewSmall[int, [4]int](alloc)
+	v.Extend([]int{1, 2, 3})
+	v.Insert(1, v.Slice()...) // values point into the inline storage
+	assert.False(v.IsInline())
+	assert.Equal([]int{1, 1, 2, 3, 2, 3}, v.Slice())
+
+	v.Truncate(2)
+	v.ShrinkToFit()
+	assert.True(v.IsInline())
+
+	v.Reserve(10)
+	assert.False(v.IsInline())
+	assert.Equal(12, v.Cap())
+
+	v.Resize(3)
+	v.ShrinkToFit()
+	assert.True(v.IsInline())
+	v.Resize(5)
+	assert.False(v.IsInline())
+	assert.Equal([]int{1, 1, 0, 0, 0}, v.Slice())
+
+	moved := v.MoveTo(alloc)
+	defer moved.Free()
+	assert.Equal([]int{1, 1, 0, 0, 0}, moved.Slice())
+
+	inline := vector.NewSmall[int, [4]int](alloc)
+	inline.ExtendSeq(slices.Values([]int{1, 2}))
+	assert.True(inline.IsInline())
+	inline.Free()
+}
+
+func TestSmallVectorAllocations(t *testing.T) {
+	assert := assert.New(t)
+
+	alloc, counts := newCountingAllocator(t)
+
+	v := vector.NewSmall[int, [8]int](alloc)
+	for i := range 8 {
+		v.Push(i)
+	}
+	assert.Equal(1, counts.allocs)
+
+	v.Push(8)
+	assert.Equal(2, counts.allocs)
+	v.Free()
+}
+
+// counts is the state of a counting allocator, it's stored in C memory and used by top level functions
+// because the allocator is copied into the vectors it allocates
+type counts struct {
+	c      allocator.Allocator
+	allocs int
+}
+
+// newCountingAllocator returns an allocator backed by the C allocator that counts its allocations
+func newCountingAllocator(t testing.TB) (allocator.Allocator, *counts) {
+	c := allocator.NewC()
+	state := allocator.Alloc[counts](c)
+	state.c = c
+	t.Cleanup(func() {
+		allocator.Free(c, state)
+		c.Destroy()
+	})
+
+	return allocator.NewAllocator(unsafe.Pointer(state), countingAlloc, countingFree, countingRealloc, countingDestroy), state
+}
+
+func countingAlloc(a unsafe.Pointer, size int) unsafe.Pointer {
+	state := (*counts)(a)
+	state.allocs++
+	return state.c.Alloc(size)
+}
+
+func countingFree(a unsafe.Pointer, ptr unsafe.Pointer) {
+	(*counts)(a).c.Free(ptr)
+}
+
+func countingRealloc(a unsafe.Pointer, ptr unsafe.Pointer, size int) unsafe.Pointer {
+	return (*counts)(a).c.Realloc(ptr, size)
+}
+
+func countingDestroy(a unsafe.Pointer) {}
+
+func TestSmallVectorInvalidStorage(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+
+	assert.Panics(t, func() {
+		vector.NewSmall[int, [4]int32](alloc)
+	})
+	assert.Panics(t, func() {
+		vector.NewSmall[int, [0]int](alloc)
+	})
+	assert.Panics(t, func() {
+		vector.NewSmall[int, int](alloc)
+	})
+}
@@ -3,22 +3,75 @@ package vector
 import (
 	"fmt"
 	"iter"
+	"slices"
+	"unsafe"
 
+	"github.com/joetifa2003/mm-go"
 	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/internal/debug"
 )
 
 // Vector a contiguous growable array type
 type Vector[T any] struct {
-	data  []T
-	len   int
-	alloc allocator.Allocator
+	data   []T `mm:"manual"`
+	len    int
+	alloc  allocator.Allocator
+	growth growthPolicy
+}
+
+type growthPolicy struct {
+	factor      float64
+	maxStep     int
+	minCapacity int
+}
+
+type VectorOption func(policy *growthPolicy)
+
+// WithGrowthFactor Option to specify how much the capacity is multiplied by when the vector grows,
+// the default is 2, factor must be greater than 1 to take effect.
+func WithGrowthFactor(factor float64) VectorOption {
+	return func(policy *growthPolicy) {
+		policy.factor = factor
+	}
+}
+
+// WithMaxGrowthStep Option to limit how many elements are added to the capacity when the vector grows,
+// this prevents huge vectors from wasting a lot of memory, step must be greater than 0 to take effect.
+func WithMaxGrowthStep(step int) VectorOption {
+	return func(policy *growthPolicy) {
+		policy.maxStep = step
+	}
+}
+
+// WithMinCapacity Option to specify the initial capacity of the vector,
+// the vector never grows to a capacity less than it.
+func WithMinCapacity(capacity int) VectorOption {
+	return func(policy *growthPolicy) {
+		policy.minCapacity = capacity
+	}
+}
+
+// next returns the capacity to grow to from cap to hold at least n elements
+func (p growthPolicy) next(cap int, n int) int {
+	newCap := cap * 2
+	if p.factor > 1 {
+		newCap = int(float64(cap) * p.factor)
+	}
+	if p.maxStep > 0 {
+		newCap = min(newCap, cap+p.maxStep)
+	}
+
+	return max(newCap, cap+1, n, p.minCapacity)
 }
 
 func createVector[T any](alloc allocator.Allocator, len int, cap int) *Vector[T] {
+	mm.CheckGoPointers[T]() // check before allocating the header so it doesn't leak
+
 	vector := allocator.Alloc[Vector[T]](alloc)
 	vector.len = len
 	vector.data = allocator.AllocMany[T](alloc, cap)
 	vector.alloc = alloc
+	debug.Track(unsafe.Pointer(vector))
 
 	return vector
 }
@@ -38,6 +91,19 @@ func New[T any](aloc allocator.Allocator, args ...int) *Vector[T] {
 	}
 }
 
+// NewWithOptions creates a new empty vector and applies optional configuration using VectorOption,
+// the growth policy is used by Push, Extend and Insert.
+func NewWithOptions[T any](alloc allocator.Allocator, options ...VectorOption) *Vector[T] {
+	var policy growthPolicy
+	for _, option := range options {
+		option(&policy)
+	}
+
+	vector := createVector[T](alloc, 0, max(policy.minCapacity, 1))
+	vector.growth = policy
+	return vector
+}
+
 // Init initializes a new vector with the T elements provided and sets
 // it's len and cap to len(values)
 func Init[T any](alloc allocator.Allocator, values ...T) *Vector[T] {
@@ -48,27 +114,133 @@ func Init[T any](alloc allocator.Allocator, values ...T) *Vector[T] {
 
 // Push pushes value T to the vector, grows if needed.
 func (v *Vector[T]) Push(value T) {
-	if v.len == v.Cap() {
-		v.data = allocator.Realloc(v.alloc, v.data, v.Cap()*2)
-	}
+	v.check()
+
+	v.grow(v.len + 1)
 
 	v.data[v.len] = value
 	v.len++
 }
 
+// Insert inserts values at the specified index, shifting the elements after it to the right.
+// idx can be equal to the length of the vector to insert at the end.
+func (v *Vector[T]) Insert(idx int, values ...T) {
+	v.check()
+
+	if idx > v.len || (debug.Enabled && idx < 0) {
+		panic(fmt.Sprintf("cannot insert %d in a vector with length %d", idx, v.len))
+	}
+
+	// values are shifted or freed by the grow if they point into the vector, so copy them first
+	if v.overlaps(values) {
+		values = slices.Clone(values)
+	}
+
+	v.grow(v.len + len(values))
+
+	copy(v.data[idx+len(values):], v.data[idx:v.len])
+	copy(v.data[idx:], values)
+	v.len += len(values)
+}
+
+// Extend appends the values to the vector, growing it at most once.
+func (v *Vector[T]) Extend(values []T) {
+	v.check()
+
+	// the grow frees values if they point into the vector, so copy them first
+	if v.len+len(values) > v.Cap() && v.overlaps(values) {
+		values = slices.Clone(values)
+	}
+
+	v.grow(v.len + len(values))
+
+	copy(v.data[v.len:], values)
+	v.len += len(values)
+}
+
+// ExtendSeq appends the values yielded by seq to the vector,
+// the number of values is not known in advance so it can grow more than once, call Reserve first if you know it.
+func (v *Vector[T]) ExtendSeq(seq iter.Seq[T]) {
+	for value := range seq {
+		v.Push(value)
+	}
+}
+
+// Reserve makes sure the vector can hold at least n more elements without growing.
+func (v *Vector[T]) Reserve(n int) {
+	v.check()
+
+	if n < 0 {
+		panic(fmt.Sprintf("cannot reserve %d elements", n))
+	}
+
+	if v.len+n > v.Cap() {
+		v.data = allocator.Realloc(v.alloc, v.data, v.len+n)
+	}
+}
+
+// Resize changes the length of the vector to n,
+// new elements are set to the zero value of T.
+func (v *Vector[T]) Resize(n int) {
+	v.check()
+
+	if n < 0 {
+		panic(fmt.Sprintf("cannot resize a vector to %d", n))
+	}
+
+	if n > v.len {
+		v.grow(n)
+		clear(v.data[v.len:n])
+	}
+	v.len = n
+}
+
+// Truncate shortens the vector to n elements,
+// it does nothing if n is greater than or equal to the length.
+func (v *Vector[T]) Truncate(n int) {
+	v.check()
+
+	if n < 0 {
+		panic(fmt.Sprintf("cannot truncate a vector to %d", n))
+	}
+
+	v.len = min(v.len, n)
+}
+
+// Clear removes all the elements from the vector, keeping its capacity.
+func (v *Vector[T]) Clear() {
+	v.check()
+	v.len = 0
+}
+
+// ShrinkToFit shrinks the capacity of the vector to its length,
+// an empty vector keeps a capacity of 1 like New.
+func (v *Vector[T]) ShrinkToFit() {
+	v.check()
+
+	newCap := max(v.len, 1)
+	if newCap < v.Cap() {
+		v.data = allocator.Realloc(v.alloc, v.data, newCap)
+	}
+}
+
 // Pop pops value T from the vector and returns it
 func (v *Vector[T]) Pop() T {
+	v.checkNotEmpty("pop")
+
 	v.len--
 	return v.data[v.len]
 }
 
 // Len gets vector length
 func (v *Vector[T]) Len() int {
+	v.check()
 	return v.len
 }
 
 // Cap gets vector capacity (underling memory length).
 func (v *Vector[T]) Cap() int {
+	v.check()
 	return cap(v.data)
 }
 
@@ -76,56 +248,96 @@ func (v *Vector[T]) Cap() int {
 // CAUTION: don't append to this slice, this is only used
 // if you want to loop on the vec elements
 func (v *Vector[T]) Slice() []T {
+	v.check()
 	return v.data[:v.len]
 }
 
 // Last gets the last element from a vector
 func (v *Vector[T]) Last() T {
+	v.checkNotEmpty("get last element of")
 	return v.data[v.len-1]
 }
 
 // At gets element T at specified index
 func (v *Vector[T]) At(idx int) T {
-	if idx >= v.len {
-		panic(fmt.Sprintf("cannot index %d in a vector with length %d", idx, v.len))
-	}
+	v.checkIndex(idx, "index")
 
 	return v.data[idx]
 }
 
 // UnsafeAT gets element T at specified index without bounds checking
+// in debug builds (mmdebug build tag) the index is checked.
 func (v *Vector[T]) UnsafeAt(idx int) T {
+	if debug.Enabled {
+		v.checkIndex(idx, "index")
+	}
+
 	return v.data[idx]
 }
 
 // AtPtr gets element a pointer of T at specified index
 func (v *Vector[T]) AtPtr(idx int) *T {
-	if idx >= v.len {
-		panic(fmt.Sprintf("cannot index %d in a vector with length %d", idx, v.len))
-	}
+	v.checkIndex(idx, "index")
 
 	return &v.data[idx]
 }
 
 // Set sets element T at specified index
 func (v *Vector[T]) Set(idx int, value T) {
-	if idx >= v.len {
-		panic(fmt.Sprintf("cannot set %d in a vector with length %d", idx, v.len))
-	}
+	v.checkIndex(idx, "set")
 
 	v.data[idx] = value
 }
 
+// Clone returns a copy of the vector using the same allocator.
+// Elements are copied shallowly, pass cloneElem to deep copy them.
+func (v *Vector[T]) Clone(cloneElem ...func(T) T) *Vector[T] {
+	return v.CloneInto(v.alloc, cloneElem...)
+}
+
+// CloneInto returns a copy of the vector allocated using alloc.
+// Elements are copied shallowly, pass cloneElem to deep copy them.
+func (v *Vector[T]) CloneInto(alloc allocator.Allocator, cloneElem ...func(T) T) *Vector[T] {
+	v.check()
+
+	clone := createVector[T](alloc, v.len, max(v.len, 1))
+	clone.growth = v.growth
+	copy(clone.data, v.data[:v.len])
+	if len(cloneElem) > 0 {
+		for i := range clone.len {
+			clone.data[i] = cloneElem[0](clone.data[i])
+		}
+	}
+
+	return clone
+}
+
+// MoveTo copies the vector into alloc and frees the original,
+// the elements are moved as is and the original vector must not be used afterwards.
+func (v *Vector[T]) MoveTo(alloc allocator.Allocator) *Vector[T] {
+	moved := v.CloneInto(alloc)
+	v.Free()
+	return moved
+}
+
 // Free deallocats the vector
 func (v *Vector[T]) Free() {
-	allocator.FreeMany[T](v.alloc, v.data)
-	allocator.Free(v.alloc, v)
+	debug.Untrack(unsafe.Pointer(v), "vector")
+
+	alloc := v.alloc
+	data := v.data
+	debug.Poison(unsafe.Pointer(unsafe.SliceData(data)), cap(data)*mm.SizeOf[T]())
+	debug.Poison(unsafe.Pointer(v), mm.SizeOf[Vector[T]]())
+
+	allocator.FreeMany[T](alloc, data)
+	allocator.Free(alloc, v)
 }
 
+// RemoveAt removes element T at specified index and returns it,
+// the last element is moved to its place, so the order of the elements is not preserved.
+// Use RemoveAtOrdered to preserve the order.
 func (v *Vector[T]) RemoveAt(idx int) T {
-	if idx >= v.len {
-		panic(fmt.Sprintf("cannot remove %d in a vector with length %d", idx, v.len))
-	}
+	v.checkIndex(idx, "remove")
 
 	tmp := v.data[idx]
 	v.data[idx] = v.data[v.len-1]
@@ -134,8 +346,90 @@ func (v *Vector[T]) RemoveAt(idx int) T {
 	return tmp
 }
 
+// RemoveAtOrdered removes element T at specified index and returns it,
+// shifting the elements after it to the left.
+func (v *Vector[T]) RemoveAtOrdered(idx int) T {
+	v.checkIndex(idx, "remove")
+
+	tmp := v.data[idx]
+	copy(v.data[idx:], v.data[idx+1:v.len])
+	v.len--
+
+	return tmp
+}
+
+// RemoveRange removes elements in the range [from, to),
+// shifting the elements after it to the left.
+func (v *Vector[T]) RemoveRange(from, to int) {
+	v.check()
+
+	if from < 0 || to > v.len || from > to {
+		panic(fmt.Sprintf("cannot remove range [%d:%d] in a vector with length %d", from, to, v.len))
+	}
+
+	copy(v.data[from:], v.data[to:v.len])
+	v.len -= to - from
+}
+
+// Retain keeps only the elements that make f return true,
+// removing the rest in place while preserving the order.
+func (v *Vector[T]) Retain(f func(T) bool) {
+	v.check()
+
+	n := 0
+	for i := 0; i < v.len; i++ {
+		if f(v.data[i]) {
+			v.data[n] = v.data[i]
+			n++
+		}
+	}
+	v.len = n
+}
+
+// Swap swaps the elements at the specified indexes
+func (v *Vector[T]) Swap(i, j int) {
+	v.checkIndex(i, "swap")
+	v.checkIndex(j, "swap")
+
+	v.data[i], v.data[j] = v.data[j], v.data[i]
+}
+
+// Sort sorts the vector in place using cmp to compare elements.
+func (v *Vector[T]) Sort(cmp func(a, b T) int) {
+	slices.SortFunc(v.Slice(), cmp)
+}
+
+// SortStable sorts the vector in place using cmp to compare elements,
+// keeping the original order of equal elements.
+func (v *Vector[T]) SortStable(cmp func(a, b T) int) {
+	slices.SortStableFunc(v.Slice(), cmp)
+}
+
+// IsSorted reports whether the vector is sorted according to cmp.
+func (v *Vector[T]) IsSorted(cmp func(a, b T) int) bool {
+	return slices.IsSortedFunc(v.Slice(), cmp)
+}
+
+// BinarySearch searches for value in a sorted vector and returns the index where it's found
+// or where it would be inserted, and whether it was found.
+func (v *Vector[T]) BinarySearch(value T, cmp func(a, b T) int) (int, bool) {
+	return slices.BinarySearchFunc(v.Slice(), value, cmp)
+}
+
+// Reverse reverses the elements of the vector in place.
+func (v *Vector[T]) Reverse() {
+	slices.Reverse(v.Slice())
+}
+
+// Dedup removes consecutive equal elements in place, keeping the first one.
+func (v *Vector[T]) Dedup(eq func(a, b T) bool) {
+	v.len = len(slices.CompactFunc(v.Slice(), eq))
+}
+
 // Iter iterates over the vector
 func (v *Vector[T]) Iter() iter.Seq2[int, T] {
+	v.check()
+
 	return func(yield func(int, T) bool) {
 		for i := 0; i < v.len; i++ {
 			if !yield(i, v.data[i]) {
@@ -144,3 +438,48 @@ func (v *Vector[T]) Iter() iter.Seq2[int, T] {
 		}
 	}
 }
+
+// grow makes sure the vector can hold n elements, growing its capacity using the growth policy if needed
+func (v *Vector[T]) grow(n int) {
+	if n <= v.Cap() {
+		return
+	}
+
+	v.data = allocator.Realloc(v.alloc, v.data, v.growth.next(v.Cap(), n))
+}
+
+// overlaps checks if values point into the memory of the vector
+func (v *Vector[T]) overlaps(values []T) bool {
+	size := unsafe.Sizeof(*new(T))
+	if len(values) == 0 || cap(v.data) == 0 || size == 0 {
+		return false
+	}
+
+	start := uintptr(unsafe.Pointer(unsafe.SliceData(v.data)))
+	end := start + uintptr(cap(v.data))*size
+	valuesStart := uintptr(unsafe.Pointer(unsafe.SliceData(values)))
+	return valuesStart < end && valuesStart+uintptr(len(values))*size > start
+}
+
+// check panics if the vector is freed, only in debug builds (mmdebug build tag)
+func (v *Vector[T]) check() {
+	debug.CheckLive(unsafe.Pointer(v), "vector")
+}
+
+func (v *Vector[T]) checkIndex(idx int, op string) {
+	v.check()
+
+	// negative indexes are only checked in debug builds (mmdebug build tag),
+	// otherwise they panic when indexing the underlying slice
+	if idx >= v.len || (debug.Enabled && idx < 0) {
+		panic(fmt.Sprintf("cannot %s %d in a vector with length %d", op, idx, v.len))
+	}
+}
+
+func (v *Vector[T]) checkNotEmpty(op string) {
+	v.check()
+
+	if debug.Enabled && v.len == 0 {
+		panic(fmt.Sprintf("cannot %s an empty vector", op))
+	}
+}
@@ -0,0 +1,43 @@
+//go:build mmdebug
+
+package vector_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/vector"
+)
+
+func TestVectorDebugBounds(t *testing.T) {
+	alloc := allocator.NewC()
+	defer alloc.Destroy()
+	assert := assert.New(t)
+
+	v := vector.New[int](alloc)
+	defer v.Free()
+
+	assert.PanicsWithValue("cannot pop an empty vector", func() { v.Pop() })
+	assert.PanicsWithValue("cannot get last element of an empty vector", func() { v.Last() })
+
+	v.Push(1)
+	assert.PanicsWithValue("cannot index -1 in a vector with length 1", func() { v.At(-1) })
+	assert.PanicsWithValue("cannot set -1 in a vector with length 1", func() { v.Set(-1, 0) })
+	assert.PanicsWithValue("cannot index 1 in a vector with length 1", func() { v.UnsafeAt(1) })
+	assert.Equal(1, v.Len())
+}
+
+func TestVectorDebugUseAfterFree(t *testing.T) {
+	alloc := allocator.NewC()
+	defer alloc.Destroy()
+	assert := assert.New(t)
+
+	v := vector.New[int](alloc)
+	v.Free()
+
+	assert.PanicsWithValue("use after free of vector", func() { v.Push(1) })
+	assert.PanicsWithValue("use after free of vector", func() { v.Len() })
+	assert.PanicsWithValue("double free of vector", func() { v.Free() })
+}
@@ -1,16 +1,19 @@
 package vector_test
 
 import (
+	"cmp"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/joetifa2003/mm-go/allocator"
+	"github.com/joetifa2003/mm-go/mmtest"
 	"github.com/joetifa2003/mm-go/vector"
 )
 
 func TestVector(t *testing.T) {
-	alloc := allocator.NewC()
+	alloc := mmtest.NewAllocator(t)
 
 	assert := assert.New(t)
 
@@ -40,7 +43,7 @@ func TestVector(t *testing.T) {
 
 func TestVectorInit(t *testing.T) {
 	t.Run("Init with no args", func(t *testing.T) {
-		alloc := allocator.NewC()
+		alloc := mmtest.NewAllocator(t)
 		assert := assert.New(t)
 
 		v := vector.New[int](alloc)
@@ -51,7 +54,7 @@ func TestVectorInit(t *testing.T) {
 	})
 
 	t.Run("Init with one arg", func(t *testing.T) {
-		alloc := allocator.NewC()
+		alloc := mmtest.NewAllocator(t)
 		assert := assert.New(t)
 
 		v := vector.New[int](alloc, 5)
@@ -62,7 +65,7 @@ func TestVectorInit(t *testing.T) {
 	})
 
 	t.Run("Init with two args", func(t *testing.T) {
-		alloc := allocator.NewC()
+		alloc := mmtest.NewAllocator(t)
 		assert := assert.New(t)
 
 		v := vector.New[int](alloc, 5, 6)
@@ -73,7 +76,7 @@ func TestVectorInit(t *testing.T) {
 	})
 
 	t.Run("Init vector with slice", func(t *testing.T) {
-		alloc := allocator.NewC()
+		alloc := mmtest.NewAllocator(t)
 		assert := assert.New(t)
 
 		v := vector.Init(alloc, 1, 2, 3)
@@ -87,3 +90,290 @@ func TestVectorInit(t *testing.T) {
 		assert.Equal(1, v.Pop())
 	})
 }
+
+func TestVectorInsertRemove(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	v := vector.Init(alloc, 1, 5)
+	defer v.Free()
+
+	v.Insert(1, 2, 3, 4)
+	assert.Equal([]int{1, 2, 3, 4, 5}, v.Slice())
+	v.Insert(0, 0)
+	v.Insert(v.Len(), 6)
+	assert.Equal([]int{0, 1, 2, 3, 4, 5, 6}, v.Slice())
+	assert.Panics(func() {
+		v.Insert(8, 1)
+	})
+
+	assert.Equal(0, v.RemoveAtOrdered(0))
+	assert.Equal([]int{1, 2, 3, 4, 5, 6}, v.Slice())
+	assert.Panics(func() {
+		v.RemoveAtOrdered(6)
+	})
+
+	v.RemoveRange(1, 3)
+	assert.Equal([]int{1, 4, 5, 6}, v.Slice())
+	v.RemoveRange(2, 2)
+	assert.Equal([]int{1, 4, 5, 6}, v.Slice())
+	assert.Panics(func() {
+		v.RemoveRange(3, 5)
+	})
+	assert.Panics(func() {
+		v.RemoveRange(2, 1)
+	})
+
+	v.Swap(0, 3)
+	assert.Equal([]int{6, 4, 5, 1}, v.Slice())
+	assert.Panics(func() {
+		v.Swap(0, 4)
+	})
+
+	v.Retain(func(i int) bool { return i%2 == 0 })
+	assert.Equal([]int{6, 4}, v.Slice())
+}
+
+func TestVectorInsertEmpty(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	v := vector.New[int](alloc, 0)
+	defer v.Free()
+
+	v.Insert(0, 1, 2, 3)
+	assert.Equal([]int{1, 2, 3}, v.Slice())
+}
+
+func TestVectorInsertOverlap(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	v := vector.New[int](alloc, 0, 8)
+	defer v.Free()
+
+	v.Extend([]int{1, 2, 3})
+	v.Insert(0, v.Slice()[1:3]...) // spare capacity, the values are shifted
+	assert.Equal([]int{2, 3, 1, 2, 3}, v.Slice())
+
+	v.ShrinkToFit()
+	v.Insert(1, v.Slice()...) // grows, the values are freed
+	assert.Equal([]int{2, 2, 3, 1, 2, 3, 3, 1, 2, 3}, v.Slice())
+}
+
+func TestVectorExtendOverlap(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	v := vector.Init(alloc, 1, 2, 3)
+	defer v.Free()
+
+	v.Extend(v.Slice()) // grows, the values are freed
+	assert.Equal([]int{1, 2, 3, 1, 2, 3}, v.Slice())
+
+	v.Reserve(2)
+	v.Extend(v.Slice()[:2]) // fits, the values are not moved
+	assert.Equal([]int{1, 2, 3, 1, 2, 3, 1, 2}, v.Slice())
+}
+
+func TestVectorCapacity(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	v := vector.New[int](alloc)
+	defer v.Free()
+
+	v.Reserve(10)
+	assert.Equal(10, v.Cap())
+	assert.Equal(0, v.Len())
+
+	v.Extend([]int{1, 2, 3})
+	v.ExtendSeq(func(yield func(int) bool) {
+		for i := 4; i <= 5; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	})
+	assert.Equal([]int{1, 2, 3, 4, 5}, v.Slice())
+	assert.Equal(10, v.Cap())
+
+	v.Truncate(2)
+	v.Truncate(5) // does nothing
+	assert.Equal([]int{1, 2}, v.Slice())
+
+	v.Resize(4) // new elements are zeroed even though the memory was used
+	assert.Equal([]int{1, 2, 0, 0}, v.Slice())
+	v.Resize(1)
+	assert.Equal([]int{1}, v.Slice())
+
+	v.ShrinkToFit()
+	assert.Equal(1, v.Cap())
+
+	v.Extend([]int{2, 3, 4})
+	assert.Equal(4, v.Cap())
+	assert.Equal([]int{1, 2, 3, 4}, v.Slice())
+
+	v.Clear()
+	assert.Equal(0, v.Len())
+	assert.Equal(4, v.Cap())
+	v.ShrinkToFit()
+	assert.Equal(1, v.Cap())
+
+	assert.Panics(func() {
+		v.Resize(-1)
+	})
+	assert.Panics(func() {
+		v.Reserve(-1)
+	})
+}
+
+func TestVectorSort(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	v := vector.Init(alloc, 3, 1, 2, 3, 1)
+	defer v.Free()
+
+	assert.False(v.IsSorted(cmp.Compare[int]))
+	v.Sort(cmp.Compare[int])
+	assert.True(v.IsSorted(cmp.Compare[int]))
+	assert.Equal([]int{1, 1, 2, 3, 3}, v.Slice())
+
+	idx, found := v.BinarySearch(2, cmp.Compare[int])
+	assert.True(found)
+	assert.Equal(2, idx)
+	idx, found = v.BinarySearch(4, cmp.Compare[int])
+	assert.False(found)
+	assert.Equal(5, idx)
+
+	v.Dedup(func(a, b int) bool { return a == b })
+	assert.Equal([]int{1, 2, 3}, v.Slice())
+
+	v.Reverse()
+	assert.Equal([]int{3, 2, 1}, v.Slice())
+}
+
+func TestVectorSortStable(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	type pair struct{ key, value int }
+	v := vector.Init(alloc, pair{2, 0}, pair{1, 1}, pair{2, 2}, pair{1, 3})
+	defer v.Free()
+
+	v.SortStable(func(a, b pair) int { return cmp.Compare(a.key, b.key) })
+	assert.Equal([]pair{{1, 1}, {1, 3}, {2, 0}, {2, 2}}, v.Slice())
+}
+
+const sortSize = 10000
+
+func BenchmarkVectorSort(b *testing.B) {
+	alloc := allocator.NewC()
+	defer alloc.Destroy()
+
+	v := vector.New[int](alloc, sortSize)
+	defer v.Free()
+
+	s := v.Slice()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for j := range sortSize {
+			s[j] = sortSize - j
+		}
+		v.Sort(cmp.Compare[int])
+	}
+}
+
+func BenchmarkSliceSortFunc(b *testing.B) {
+	s := make([]int, sortSize)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for j := range sortSize {
+			s[j] = sortSize - j
+		}
+		slices.SortFunc(s, cmp.Compare[int])
+	}
+}
+
+func TestVectorClone(t *testing.T) {
+	scratch := mmtest.NewAllocator(t)
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	v := vector.Init(scratch, 1, 2, 3)
+
+	clone := v.Clone()
+	defer clone.Free()
+	clone.Set(0, 10)
+	assert.Equal([]int{1, 2, 3}, v.Slice())
+	assert.Equal([]int{10, 2, 3}, clone.Slice())
+
+	moved := v.MoveTo(alloc)
+	defer moved.Free()
+	assert.Equal([]int{1, 2, 3}, moved.Slice())
+
+	nested := vector.New[*vector.Vector[int]](alloc)
+	defer nested.Free()
+	nested.Push(vector.Init(alloc, 1))
+
+	deep := nested.CloneInto(alloc, func(v *vector.Vector[int]) *vector.Vector[int] {
+		return v.Clone()
+	})
+	defer deep.Free()
+	assert.NotSame(nested.At(0), deep.At(0))
+	assert.Equal([]int{1}, deep.At(0).Slice())
+
+	nested.At(0).Free()
+	deep.At(0).Free()
+}
+
+func TestVectorGrowthPolicy(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	v := vector.NewWithOptions[int](alloc,
+		vector.WithMinCapacity(4),
+		vector.WithGrowthFactor(1.5),
+		vector.WithMaxGrowthStep(8),
+	)
+	defer v.Free()
+	assert.Equal(4, v.Cap())
+
+	caps := []int{}
+	for i := range 40 {
+		v.Push(i)
+		if len(caps) == 0 || caps[len(caps)-1] != v.Cap() {
+			caps = append(caps, v.Cap())
+		}
+	}
+	assert.Equal([]int{4, 6, 9, 13, 19, 27, 35, 43}, caps)
+
+	// growing to hold more than one step at once
+	v.Extend(make([]int, 20))
+	assert.Equal(60, v.Cap())
+	v.Insert(0, 1)
+	assert.Equal(68, v.Cap())
+
+	clone := v.Clone()
+	defer clone.Free()
+	clone.ShrinkToFit()
+	clone.Push(1)
+	assert.Equal(69, clone.Cap())
+}
+
+func TestVectorGrowthPolicyDefaults(t *testing.T) {
+	alloc := mmtest.NewAllocator(t)
+	assert := assert.New(t)
+
+	v := vector.NewWithOptions[int](alloc, vector.WithGrowthFactor(0.5))
+	defer v.Free()
+	assert.Equal(1, v.Cap())
+
+	for i := range 5 {
+		v.Push(i)
+	}
+	assert.Equal(8, v.Cap())
+}